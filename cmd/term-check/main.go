@@ -18,6 +18,8 @@ var debug = flag.Bool("debug", os.Getenv("LOG_LEVEL") == "debug", "sets log leve
 func main() {
 	zerolog.TimeFieldFormat = ""
 	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	// Events carry a logger tagged with their delivery ID, anything else logs with the global logger
+	zerolog.DefaultContextLogger = &log.Logger
 
 	flag.Parse()
 
@@ -29,5 +31,11 @@ func main() {
 	c := config.New(*filepath)
 
 	log.Info().Msg("Starting service...")
-	bot.New(c.ForBot, c.ForClient, c.ForServer).Start()
+	b, err := bot.New(c.ForBot, c.ForClient, c.ForServer)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create bot")
+	}
+	if err := b.Start(); err != nil {
+		log.Fatal().Err(err).Msg("Bot stopped")
+	}
 }
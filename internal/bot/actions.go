@@ -0,0 +1,66 @@
+package bot
+
+import (
+	"context"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/rs/zerolog/log"
+)
+
+// Identifiers of the actions offered on completed check runs. GitHub limits identifiers to 20 characters.
+const (
+	ignoreActionIdentifier = "ignore_pr"
+	fixActionIdentifier    = "apply_fixes"
+)
+
+// checkRunActions returns the actions to offer on a check run with the passed in findings
+func (b *Bot) checkRunActions(findings []finding) []*github.CheckRunAction {
+	if len(findings) == 0 {
+		return nil
+	}
+
+	actions := []*github.CheckRunAction{{
+		Label:       "Ignore for this PR",
+		Description: "Pass the check for this pull request",
+		Identifier:  ignoreActionIdentifier,
+	}}
+
+	for _, f := range findings {
+		if _, ok := b.suggest(f); ok {
+			actions = append(actions, &github.CheckRunAction{
+				Label:       "Apply fixes",
+				Description: "Commit the suggested alternatives",
+				Identifier:  fixActionIdentifier,
+			})
+			break
+		}
+	}
+
+	return actions
+}
+
+// handleRequestedAction carries out an action a user requested from a check run on a pull request
+func (b *Bot) handleRequestedAction(ctx context.Context, identifier string, sender string, cr *github.CheckRun, pr *github.PullRequest, r *github.Repository, ghc *github.Client) {
+	headSHA := pr.GetHead().GetSHA()
+
+	switch identifier {
+	case ignoreActionIdentifier:
+		if err := b.ignorePullRequest(ctx, pr, r, ghc, sender); err != nil {
+			log.Ctx(ctx).Error().Str("SHA", headSHA).Err(err).Msg("Failed to ignore pull request")
+			return
+		}
+		log.Ctx(ctx).Info().Str("SHA", headSHA).Msgf("Pull request ignored by %s", sender)
+
+		b.createCheckRun(ctx, pr, r, ghc)
+	case fixActionIdentifier:
+		fixes, commitSHA, err := b.applyFixes(ctx, pr, r, ghc)
+		if err != nil {
+			log.Ctx(ctx).Error().Str("SHA", headSHA).Err(err).Msg("Failed to apply fixes")
+		} else {
+			log.Ctx(ctx).Info().Str("SHA", headSHA).Msgf("Fixes applied in %s by request of %s", commitSHA, sender)
+		}
+		b.reportFixes(ctx, cr, r, ghc, fixes, commitSHA, err)
+	default:
+		log.Ctx(ctx).Debug().Str("SHA", headSHA).Msgf("Unknown requested action received: %s. Discarding...", identifier)
+	}
+}
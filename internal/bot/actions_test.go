@@ -0,0 +1,52 @@
+package bot
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type checkRunActionsTestCase struct {
+	name     string
+	findings []finding
+	expected []string
+}
+
+func TestCheckRunActions(t *testing.T) {
+	b := &Bot{terms: []term{
+		{source: "master", pattern: regexp.MustCompile("master"), alternatives: []string{"main"}},
+		{source: "slave", pattern: regexp.MustCompile("slave")},
+	}}
+
+	cases := []checkRunActionsTestCase{
+		{
+			name:     "NoFindings",
+			findings: nil,
+			expected: nil,
+		},
+		{
+			name:     "NothingToFix",
+			findings: []finding{{content: "a slave process", terms: []string{"slave"}, spans: [][]int{{2, 7}}}},
+			expected: []string{ignoreActionIdentifier},
+		},
+		{
+			name: "Fixable",
+			findings: []finding{
+				{content: "a slave process", terms: []string{"slave"}, spans: [][]int{{2, 7}}},
+				{content: "the master branch", terms: []string{"master"}, spans: [][]int{{4, 10}}},
+			},
+			expected: []string{ignoreActionIdentifier, fixActionIdentifier},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var identifiers []string
+			for _, a := range b.checkRunActions(tc.findings) {
+				identifiers = append(identifiers, a.Identifier)
+			}
+			assert.Equal(t, tc.expected, identifiers)
+		})
+	}
+}
@@ -0,0 +1,332 @@
+package bot
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	"github.com/zendesk/term-check/internal/config"
+)
+
+// auditCheckSuffix is appended to the check name for the audit check run on an organization's admin repo
+const auditCheckSuffix = " / audit"
+
+// maxCheckRunText is the longest text GitHub accepts in a check run's output
+const maxCheckRunText = 65535
+
+// auditRequest is an audit started on demand, queued to be handled like webhook events
+type auditRequest struct {
+	owner          string
+	installationID int
+}
+
+// auditReport is the result of auditing the metadata of all repositories of one installation. Partial reports only
+// hold the repositories changed since the bot started, until a full audit runs.
+type auditReport struct {
+	Owner        string      `json:"owner"`
+	GeneratedAt  time.Time   `json:"generatedAt"`
+	Partial      bool        `json:"partial"`
+	Repositories []repoAudit `json:"repositories"`
+}
+
+// repoAudit holds the flagged terms found in the metadata of one repository
+type repoAudit struct {
+	Repository string         `json:"repository"`
+	Findings   []auditFinding `json:"findings"`
+}
+
+// auditFinding is a piece of repository metadata using flagged terms
+type auditFinding struct {
+	Field string   `json:"field"`
+	Value string   `json:"value"`
+	Terms []string `json:"terms"`
+}
+
+// runAudit audits the metadata of every repository the installation can access, keeps the report to be served as
+// JSON, and publishes it as a check run on the owner's admin repo
+func (b *Bot) runAudit(ctx context.Context, owner string, ghc *github.Client) {
+	// Tokens have no installation to list the repositories of
+	if b.tokenMode() {
+		log.Ctx(ctx).Info().Str("Owner", owner).Msg("Audits are not run in token mode. Discarding...")
+		return
+	}
+
+	log.Ctx(ctx).Info().Str("Owner", owner).Msg("Auditing repositories...")
+
+	report := &auditReport{Owner: owner, GeneratedAt: time.Now()}
+
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		repos, resp, err := ghc.Apps.ListRepos(ctx, opts)
+		if err != nil {
+			log.Ctx(ctx).Error().Str("Owner", owner).Err(err).Msg("Failed to list repositories")
+			return
+		}
+
+		for _, r := range repos {
+			ra, err := b.auditRepository(ctx, r, ghc)
+			if err != nil {
+				log.Ctx(ctx).Error().Str("Repo", r.GetFullName()).Err(err).Msg("Failed to audit repository")
+				continue
+			}
+			report.Repositories = append(report.Repositories, ra)
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	b.storeAudit(report)
+	b.publishAudit(ctx, report, ghc)
+}
+
+// updateAudit audits a single repository, replacing its entry in the owner's latest report. When there is no report
+// yet, e.x. after a restart, a partial report of just the repository is started rather than auditing every repository
+// from a single event.
+func (b *Bot) updateAudit(ctx context.Context, r *github.Repository, ghc *github.Client) {
+	owner := r.GetOwner().GetLogin()
+
+	b.auditMu.Lock()
+	previous, ok := b.audits[strings.ToLower(owner)]
+	b.auditMu.Unlock()
+	if !ok {
+		previous = &auditReport{Owner: owner, Partial: true}
+	}
+
+	ra, err := b.auditRepository(ctx, r, ghc)
+	if err != nil {
+		log.Ctx(ctx).Error().Str("Repo", r.GetFullName()).Err(err).Msg("Failed to audit repository")
+		return
+	}
+
+	report := &auditReport{Owner: owner, GeneratedAt: time.Now(), Partial: previous.Partial, Repositories: []repoAudit{ra}}
+	for _, existing := range previous.Repositories {
+		if existing.Repository != ra.Repository {
+			report.Repositories = append(report.Repositories, existing)
+		}
+	}
+	sort.Slice(report.Repositories, func(i, j int) bool {
+		return report.Repositories[i].Repository < report.Repositories[j].Repository
+	})
+
+	b.storeAudit(report)
+	b.publishAudit(ctx, report, ghc)
+}
+
+// auditRepository checks the description, topics, branch names and default branch name of a repository
+func (b *Bot) auditRepository(ctx context.Context, r *github.Repository, ghc *github.Client) (repoAudit, error) {
+	owner, name := r.GetOwner().GetLogin(), r.GetName()
+	ra := repoAudit{Repository: r.GetFullName()}
+
+	add := func(field, value string) {
+		if terms := b.findInText(value); len(terms) > 0 {
+			ra.Findings = append(ra.Findings, auditFinding{Field: field, Value: value, Terms: terms})
+		}
+	}
+
+	add("description", r.GetDescription())
+
+	topics, _, err := ghc.Repositories.ListAllTopics(ctx, owner, name)
+	if err != nil {
+		return ra, fmt.Errorf("Failed to list topics of %s: %s", r.GetFullName(), err)
+	}
+	for _, t := range topics {
+		add("topic", t)
+	}
+
+	add("default branch", r.GetDefaultBranch())
+
+	opts := &github.BranchListOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		branches, resp, err := ghc.Repositories.ListBranches(ctx, owner, name, opts)
+		if err != nil {
+			return ra, fmt.Errorf("Failed to list branches of %s: %s", r.GetFullName(), err)
+		}
+
+		for _, br := range branches {
+			if br.GetName() != r.GetDefaultBranch() {
+				add("branch", br.GetName())
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return ra, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+func (b *Bot) storeAudit(report *auditReport) {
+	b.auditMu.Lock()
+	defer b.auditMu.Unlock()
+
+	// Logins are case insensitive
+	b.audits[strings.ToLower(report.Owner)] = report
+}
+
+// publishAudit reports an audit as a check run on the head of the default branch of the owner's admin repo
+func (b *Bot) publishAudit(ctx context.Context, report *auditReport, ghc *github.Client) {
+	owner := report.Owner
+
+	admin, _, err := ghc.Repositories.Get(ctx, owner, b.auditRepo)
+	if err != nil {
+		log.Ctx(ctx).Error().Str("Owner", owner).Err(err).Msgf("Failed to get audit repository %s", b.auditRepo)
+		return
+	}
+	headSHA, _, err := ghc.Repositories.GetCommitSHA1(ctx, owner, b.auditRepo, admin.GetDefaultBranch(), "")
+	if err != nil {
+		log.Ctx(ctx).Error().Str("Owner", owner).Err(err).Msgf("Failed to get head of audit repository %s", b.auditRepo)
+		return
+	}
+
+	count := 0
+	for _, ra := range report.Repositories {
+		count += len(ra.Findings)
+	}
+
+	opts := github.CreateCheckRunOptions{
+		Name:        b.checkName + auditCheckSuffix,
+		HeadSHA:     headSHA,
+		Status:      github.String("completed"),
+		Conclusion:  github.String(checkSuccessConclusion),
+		CompletedAt: &github.Timestamp{Time: report.GeneratedAt},
+		Output: &github.CheckRunOutput{
+			Title:   github.String(b.checkName + auditCheckSuffix),
+			Summary: github.String(b.checkSuccessSummary),
+		},
+	}
+	if count > 0 {
+		// The audit informs admins, there is nothing to block
+		opts.Conclusion = github.String(modeConclusions[config.AdvisoryMode])
+		opts.Output.Summary = github.String(fmt.Sprintf("%s\n\nRepository metadata using flagged terms: **%d**", b.checkFailureSummary, count))
+		opts.Output.Text = github.String(b.auditText(report))
+	}
+
+	if report.Partial {
+		partial := fmt.Sprintf("\n\nOnly repositories changed since %s started are included. Rerun this check for a full audit.", b.checkName)
+		opts.Output.Summary = github.String(opts.Output.GetSummary() + partial)
+	}
+
+	if err := b.reportOnCommit(ctx, admin, opts, admin.GetHTMLURL(), ghc); err != nil {
+		log.Ctx(ctx).Error().Str("SHA", headSHA).Err(err).Msg("Failed to publish audit")
+		return
+	}
+	log.Ctx(ctx).Info().Str("Owner", owner).Msgf("Published audit with %d finding(s)", count)
+}
+
+// auditText renders the findings of an audit, truncated to what fits in a check run
+func (b *Bot) auditText(report *auditReport) string {
+	var sb strings.Builder
+
+	fmt.Fprint(&sb, "| Repository | Field | Value | Term | Suggestion |\n| --- | --- | --- | --- | --- |\n")
+	for _, ra := range report.Repositories {
+		for _, f := range ra.Findings {
+			for _, m := range f.Terms {
+				suggestion := ""
+				if t, ok := b.lookupTerm(m); ok {
+					suggestion = tableCell(strings.Join(t.alternatives, ", "))
+				}
+				// Values such as descriptions and topics are set by anyone able to edit the repository
+				row := fmt.Sprintf("| %s | %s | %s | %s | %s |\n", tableCell(ra.Repository), tableCell(f.Field), codeSpan(f.Value), codeSpan(m), suggestion)
+
+				if sb.Len()+len(row) > maxCheckRunText-100 {
+					fmt.Fprint(&sb, "\nTruncated, see the JSON report for all findings.\n")
+					return sb.String()
+				}
+				sb.WriteString(row)
+			}
+		}
+	}
+
+	return sb.String()
+}
+
+// serveAudit responds with the latest audit report of an owner as JSON
+func (b *Bot) serveAudit(w http.ResponseWriter, r *http.Request) {
+	if !b.authorizedForAudit(r) {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	owner := mux.Vars(r)["owner"]
+
+	b.auditMu.Lock()
+	report, ok := b.audits[strings.ToLower(owner)]
+	b.auditMu.Unlock()
+	if !ok {
+		http.Error(w, fmt.Sprintf("No audit of %s has run yet", owner), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Ctx(r.Context()).Error().Str("Owner", owner).Err(err).Msg("Failed to write audit report")
+	}
+}
+
+// triggerAudit starts an audit of an owner's repositories on demand. The audit is queued like webhook events, as it
+// can take longer than the request is allowed to.
+func (b *Bot) triggerAudit(w http.ResponseWriter, r *http.Request) {
+	if !b.authorizedForAudit(r) {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	owner := mux.Vars(r)["owner"]
+
+	installationID, err := b.findInstallation(r.Context(), owner)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Str("Owner", owner).Err(err).Msg("Failed to find installation")
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err := b.server.Enqueue(r.Context(), &auditRequest{owner: owner, installationID: installationID}); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// authorizedForAudit returns whether a request carries the audit token as a bearer token
+func (b *Bot) authorizedForAudit(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(b.auditToken)) == 1
+}
+
+// findInstallation returns the ID of the application's installation on an account
+func (b *Bot) findInstallation(ctx context.Context, owner string) (int, error) {
+	appClient, err := b.client.CreateAppClient()
+	if err != nil {
+		return 0, err
+	}
+
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		installations, resp, err := appClient.Apps.ListInstallations(ctx, opts)
+		if err != nil {
+			return 0, fmt.Errorf("Failed to list installations: %s", err)
+		}
+
+		for _, i := range installations {
+			if strings.EqualFold(i.GetAccount().GetLogin(), owner) {
+				return int(i.GetID()), nil // truncating
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return 0, fmt.Errorf("No installation found on %s", owner)
+		}
+		opts.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,182 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/zendesk/term-check/internal/githubtest"
+)
+
+// newAuditBot returns a bot flagging master, publishing audits to the zendesk/admin repo
+func newAuditBot() *Bot {
+	return &Bot{
+		appID:               1,
+		checkName:           "term-check",
+		checkSuccessSummary: "All good.",
+		checkFailureSummary: "Flagged terms found.",
+		auditRepo:           "admin",
+		auditToken:          "secret",
+		audits:              make(map[string]*auditReport),
+		termPattern:         regexp.MustCompile("(?i)master"),
+		terms:               []term{{source: "(?i)master", pattern: regexp.MustCompile("(?i)master"), alternatives: []string{"main"}}},
+	}
+}
+
+// newFakeAuditAPI starts a fake GitHub API serving the topics and branches of the test repo, and the admin repo
+// audits are published to
+func newFakeAuditAPI(t *testing.T) *githubtest.Server {
+	s := githubtest.NewServer(t)
+	s.Respond("GET /repos/zendesk/term-check/topics", http.StatusOK, `{"names": ["go", "master-data"]}`)
+	s.Respond("GET /repos/zendesk/term-check/branches", http.StatusOK, `[{"name": "master"}, {"name": "feature"}, {"name": "old-master"}]`)
+	s.Respond("GET /repos/zendesk/admin", http.StatusOK, `{"name": "admin", "owner": {"login": "zendesk"}, "default_branch": "main"}`)
+	s.Respond("GET /repos/zendesk/admin/commits/main", http.StatusOK, "abc")
+	s.Respond("GET /repos/zendesk/admin/commits/abc/check-runs", http.StatusOK, `{"total_count": 0, "check_runs": []}`)
+	s.Respond("POST /repos/zendesk/admin/check-runs", http.StatusCreated, `{"id": 1}`)
+	return s
+}
+
+// auditedRepo is the test repo, as sent with events, with flagged terms in its metadata
+var auditedRepo = &github.Repository{
+	Name:          github.String("term-check"),
+	FullName:      github.String("zendesk/term-check"),
+	Owner:         &github.User{Login: github.String("zendesk")},
+	Description:   github.String("Flags terms like master"),
+	DefaultBranch: github.String("master"),
+}
+
+func TestAuditRepository(t *testing.T) {
+	s := newFakeAuditAPI(t)
+
+	ra, err := newAuditBot().auditRepository(context.Background(), auditedRepo, s.Client)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "zendesk/term-check", ra.Repository)
+		// The default branch is only reported once, as the default branch
+		assert.Equal(t, []auditFinding{
+			{Field: "description", Value: "Flags terms like master", Terms: []string{"master"}},
+			{Field: "topic", Value: "master-data", Terms: []string{"master"}},
+			{Field: "default branch", Value: "master", Terms: []string{"master"}},
+			{Field: "branch", Value: "old-master", Terms: []string{"master"}},
+		}, ra.Findings)
+	}
+}
+
+func TestAuditText(t *testing.T) {
+	report := &auditReport{Owner: "zendesk", Repositories: []repoAudit{{
+		Repository: "zendesk/term-check",
+		Findings: []auditFinding{
+			{Field: "description", Value: "Use `master` | not\nmain", Terms: []string{"master"}},
+			{Field: "branch", Value: "master", Terms: []string{"master"}},
+		},
+	}}}
+
+	text := newAuditBot().auditText(report)
+
+	assert.Equal(t, strings.Join([]string{
+		"| Repository | Field | Value | Term | Suggestion |",
+		"| --- | --- | --- | --- | --- |",
+		"| zendesk/term-check | description | ``Use `master` \\| not main`` | `master` | main |",
+		"| zendesk/term-check | branch | `master` | `master` | main |",
+		"",
+	}, "\n"), text)
+}
+
+type updateAuditTestCase struct {
+	name                 string
+	previous             *auditReport
+	expectedPartial      bool
+	expectedRepositories []string
+}
+
+func TestUpdateAudit(t *testing.T) {
+	cases := []updateAuditTestCase{
+		{
+			name:                 "StartsPartialReport",
+			previous:             nil,
+			expectedPartial:      true,
+			expectedRepositories: []string{"zendesk/term-check"},
+		},
+		{
+			name: "ReplacesRepositoryInReport",
+			previous: &auditReport{Owner: "zendesk", Repositories: []repoAudit{
+				{Repository: "zendesk/term-check"},
+				{Repository: "zendesk/zendesk"},
+				{Repository: "zendesk/app"},
+			}},
+			expectedPartial:      false,
+			expectedRepositories: []string{"zendesk/app", "zendesk/term-check", "zendesk/zendesk"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newFakeAuditAPI(t)
+			b := newAuditBot()
+			if tc.previous != nil {
+				b.storeAudit(tc.previous)
+			}
+
+			b.updateAudit(context.Background(), auditedRepo, s.Client)
+
+			report := b.audits["zendesk"]
+			assert.Equal(t, tc.expectedPartial, report.Partial)
+			var repositories []string
+			for _, ra := range report.Repositories {
+				repositories = append(repositories, ra.Repository)
+			}
+			assert.Equal(t, tc.expectedRepositories, repositories)
+
+			cr := s.Body("POST /repos/zendesk/admin/check-runs")
+			if assert.NotNil(t, cr) {
+				assert.Equal(t, "term-check / audit", cr["name"])
+				summary := cr["output"].(map[string]interface{})["summary"].(string)
+				assert.Equal(t, tc.expectedPartial, strings.Contains(summary, "Rerun this check for a full audit"))
+			}
+		})
+	}
+}
+
+type serveAuditTestCase struct {
+	name           string
+	owner          string
+	token          string
+	expectedStatus int
+}
+
+func TestServeAudit(t *testing.T) {
+	b := newAuditBot()
+	b.storeAudit(&auditReport{Owner: "Zendesk", GeneratedAt: time.Now()})
+
+	cases := []serveAuditTestCase{
+		{name: "Served", owner: "zendesk", token: "secret", expectedStatus: http.StatusOK},
+		{name: "WrongToken", owner: "zendesk", token: "guess", expectedStatus: http.StatusUnauthorized},
+		{name: "NoAuditYet", owner: "github", token: "secret", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/audits/"+tc.owner, nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			req = mux.SetURLVars(req, map[string]string{"owner": tc.owner})
+			w := httptest.NewRecorder()
+
+			b.serveAudit(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusOK {
+				var report auditReport
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+				assert.Equal(t, "Zendesk", report.Owner)
+			}
+		})
+	}
+}
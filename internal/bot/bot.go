@@ -5,10 +5,13 @@ package bot
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/go-github/v32/github"
@@ -17,13 +20,13 @@ import (
 	ignore "github.com/sabhiram/go-gitignore"
 	"github.com/waigani/diffparser"
 	"github.com/zendesk/term-check/internal/config"
+	"github.com/zendesk/term-check/pkg/extract"
 	gh "github.com/zendesk/term-check/pkg/github"
 	"github.com/zendesk/term-check/pkg/lib"
 )
 
 const (
 	checkSuccessConclusion  = "success"
-	checkFailureConclusion  = "neutral"
 	checkRunAnnotationLevel = "warning"
 )
 
@@ -32,13 +35,37 @@ var (
 		"rerequested": {},
 	}
 	checkRunRelevantActions = map[string]struct{}{
-		"rerequested": {},
+		"rerequested":      {},
+		"requested_action": {},
+	}
+	discussionRelevantActions = map[string]struct{}{
+		"created": {},
+		"edited":  {},
+	}
+	issueRelevantActions = map[string]struct{}{
+		"opened": {},
+		"edited": {},
+	}
+	issueCommentRelevantActions = map[string]struct{}{
+		"created": {},
+	}
+	mergeGroupRelevantActions = map[string]struct{}{
+		"checks_requested": {},
 	}
 	pullRequestRelevantActions = map[string]struct{}{
 		"opened":      {},
 		"reopened":    {},
 		"synchronize": {},
 	}
+	releaseRelevantActions = map[string]struct{}{
+		"created":     {},
+		"edited":      {},
+		"prereleased": {},
+	}
+	repositoryRelevantActions = map[string]struct{}{
+		"created": {},
+		"edited":  {},
+	}
 )
 
 // Bot is a type containing config for the GitHub bot logic
@@ -46,151 +73,634 @@ type Bot struct {
 	client              *gh.Client
 	server              *gh.Server
 	appID               int
-	termList            []string
+	termPattern         *regexp.Regexp
+	terms               []term
 	checkName           string
 	checkSuccessSummary string
 	checkFailureSummary string
 	checkDetails        string
 	annotationTitle     string
 	annotationBody      string
+	suggestChanges      bool
+	summaryComment      bool
+	onboarding          bool
+	mode                string
+	scanIssues          bool
+	wikiCloneDir        string
+	wikiMu              sync.Mutex
+	wikiLocks           map[string]*sync.Mutex
+	wikiGitErr          error
+	auditRepo           string
+	auditToken          string
+	auditMu             sync.Mutex
+	audits              map[string]*auditReport
+	withToken           bool
+	identityMu          sync.Mutex
+	tokenLogin          string
+	appSlug             string
+	uploadSARIF         bool
+	checked             *lib.TTLSet
+}
+
+// term is a flagged term from the configuration, compiled for matching
+type term struct {
+	source       string
+	pattern      *regexp.Regexp
+	alternatives []string
+	category     string
 }
 
 // New creates a new instance of Bot, taking in BotOptions
-func New(botConfig *config.BotConfig, clientConfig *config.ClientConfig, serverConfig *config.ServerConfig) *Bot {
+func New(botConfig *config.BotConfig, clientConfig *config.ClientConfig, serverConfig *config.ServerConfig) (*Bot, error) {
 	zerolog.TimeFieldFormat = ""
 
 	b := Bot{
 		appID:               botConfig.AppID,
-		termList:            botConfig.TermList,
 		checkName:           botConfig.CheckName,
 		checkSuccessSummary: botConfig.CheckSuccessSummary,
 		checkFailureSummary: botConfig.CheckFailureSummary,
 		checkDetails:        botConfig.CheckDetails,
 		annotationTitle:     botConfig.AnnotationTitle,
 		annotationBody:      botConfig.AnnotationBody,
+		suggestChanges:      botConfig.SuggestChanges,
+		summaryComment:      botConfig.SummaryComment,
+		onboarding:          botConfig.Onboarding,
+		mode:                botConfig.Mode,
+		scanIssues:          botConfig.ScanIssues,
+		wikiCloneDir:        botConfig.WikiCloneDir,
+		wikiLocks:           make(map[string]*sync.Mutex),
+		auditRepo:           botConfig.AuditRepo,
+		auditToken:          botConfig.AuditToken,
+		audits:              make(map[string]*auditReport),
+		checked:             lib.NewTTLSet(serverConfig.DedupTTL),
+		uploadSARIF:         botConfig.UploadSARIF,
+		withToken:           clientConfig.Token != "",
 	}
 
-	b.client = gh.NewClient(
+	patterns := make([]string, 0, len(botConfig.TermList))
+	for _, t := range botConfig.TermList {
+		re, err := regexp.Compile(t.Term)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Failed to compile term %s", t.Term)
+		}
+		b.terms = append(b.terms, term{source: t.Term, pattern: re, alternatives: t.Alternatives, category: t.Category})
+		patterns = append(patterns, t.Term)
+	}
+	b.termPattern = regexp.MustCompile(strings.Join(patterns, "|"))
+
+	// Wiki checks are opt in per repo, so the app still starts without a suitable git and only refuses to check wikis
+	if err := checkGitVersion(context.Background()); err != nil {
+		b.wikiGitErr = err
+		log.Error().Err(err).Msg("Wiki pages will not be checked")
+	}
+
+	client, err := gh.NewClient(
 		gh.WithPrivateKeyPath(clientConfig.PrivateKeyPath),
 		gh.WithAppID(clientConfig.AppID),
+		gh.WithBaseURL(clientConfig.BaseURL),
+		gh.WithUploadURL(clientConfig.UploadURL),
+		gh.WithToken(clientConfig.Token),
 	)
+	if err != nil {
+		return nil, err
+	}
+	b.client = client
 
-	b.server = gh.NewServer(
+	serverOptions := []gh.ServerOption{
 		gh.WithWebhookSecretKey(serverConfig.WebhookSecretKey),
 		gh.WithEventHandler(&b),
-	)
+		gh.WithWorkers(serverConfig.Workers),
+		gh.WithQueueDepth(serverConfig.QueueDepth),
+		gh.WithJobTimeout(serverConfig.JobTimeout),
+		gh.WithShutdownGracePeriod(serverConfig.ShutdownGracePeriod),
+		gh.WithAddress(net.JoinHostPort(serverConfig.Address, strconv.Itoa(serverConfig.Port))),
+		gh.WithTimeouts(serverConfig.ReadTimeout, serverConfig.WriteTimeout, serverConfig.IdleTimeout),
+		gh.WithWebhookPath(serverConfig.WebhookPath),
+		gh.WithTLS([]byte(serverConfig.TLSCert), []byte(serverConfig.TLSKey)),
+		gh.WithDedupTTL(serverConfig.DedupTTL),
+	}
+	if b.auditToken != "" {
+		serverOptions = append(serverOptions,
+			gh.WithHandler("GET", "/audits/{owner}", b.serveAudit),
+			gh.WithHandler("POST", "/audits/{owner}", b.triggerAudit),
+		)
+	}
+	b.server = gh.NewServer(serverOptions...)
 
-	return &b
+	return &b, nil
 }
 
-// Start starts the bot server
-func (b *Bot) Start() {
+// Start starts the bot server, returning once it is shut down
+func (b *Bot) Start() error {
 	log.Debug().Msg("Starting bot...")
 
-	b.server.Start()
+	return b.server.Start()
+}
+
+// installationEvent is an event sent for an installation of the app, as every webhook event handled is
+type installationEvent interface {
+	GetInstallation() *github.Installation
 }
 
 // HandleEvent interface implementation for Server to pass incoming GitHub events to
-func (b *Bot) HandleEvent(event interface{}) {
+func (b *Bot) HandleEvent(ctx context.Context, event interface{}) {
+	var installationID int
+	switch event := event.(type) {
+	case installationEvent:
+		installationID = int(event.GetInstallation().GetID()) // truncating
+	case *auditRequest:
+		installationID = event.installationID
+	default:
+		log.Ctx(ctx).Debug().Msgf("Unhandled event received: %s. Discarding...", reflect.TypeOf(event).Elem().Name())
+		return
+	}
+
+	// Clients are kept per installation, creating one does not reach GitHub
+	gClient, err := b.client.CreateClient(installationID)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to create GitHub client")
+		return
+	}
+
 	switch event := event.(type) {
 	case *github.CheckSuiteEvent:
-		i := event.GetInstallation()
-		cs := event.GetCheckSuite()
+		b.handleCheckSuite(ctx, event, gClient)
+	case *github.CheckRunEvent:
+		b.handleCheckRun(ctx, event, gClient)
+	case *github.PullRequestEvent:
+		b.handlePullRequest(ctx, event, gClient)
+	case *github.IssueCommentEvent:
+		b.handleIssueComment(ctx, event, gClient)
+	case *github.InstallationEvent:
+		b.handleInstallation(ctx, event, gClient)
+	case *github.InstallationRepositoriesEvent:
+		b.handleInstallationRepositories(ctx, event, gClient)
+	case *github.IssuesEvent:
+		b.handleIssues(ctx, event, gClient)
+	case *gh.DiscussionEvent:
+		b.handleDiscussion(ctx, event, gClient)
+	case *gh.MergeGroupEvent:
+		b.handleMergeGroup(ctx, event, gClient)
+	case *github.ReleaseEvent:
+		b.handleRelease(ctx, event, gClient)
+	case *github.CreateEvent:
+		b.handleCreate(ctx, event, gClient)
+	case *github.RepositoryEvent:
+		b.handleRepository(ctx, event, gClient)
+	case *github.GollumEvent:
+		log.Ctx(ctx).Info().Str("Repo", event.GetRepo().GetFullName()).Msgf("GollumEvent received")
+		b.checkWiki(ctx, event.Pages, event.GetRepo(), installationID, gClient)
+	case *auditRequest:
+		log.Ctx(ctx).Info().Str("Owner", event.owner).Msg("Audit requested")
+		b.runAudit(ctx, event.owner, gClient)
+	default:
+		log.Ctx(ctx).Debug().Msgf("Unhandled event received: %s. Discarding...", reflect.TypeOf(event).Elem().Name())
+	}
+}
 
-		var shas strings.Builder
-		for _, pr := range cs.PullRequests {
-			fmt.Fprintf(&shas, "%s ", pr.GetHead().GetSHA())
-		}
-		shasString := strings.TrimSpace(shas.String())
+// handleCheckSuite checks the pull requests of a check suite requested for the app
+func (b *Bot) handleCheckSuite(ctx context.Context, event *github.CheckSuiteEvent, ghc *github.Client) {
+	cs := event.GetCheckSuite()
 
-		if id := cs.GetApp().GetID(); id != int64(b.appID) {
-			log.Debug().Str("SHA", shasString).Msg("CheckSuiteEvent received")
-			log.Debug().Str("SHA", shasString).Msgf("\tEvent App ID of %d does not match Bot's App ID of %d", id, b.appID)
-			return
-		}
+	var shas strings.Builder
+	for _, pr := range cs.PullRequests {
+		fmt.Fprintf(&shas, "%s ", pr.GetHead().GetSHA())
+	}
+	shasString := strings.TrimSpace(shas.String())
 
-		if action := event.GetAction(); !lib.Contains(checkSuiteRelevantActions, action) {
-			log.Debug().Str("SHA", shasString).Msg("CheckSuiteEvent received")
-			log.Debug().Str("SHA", shasString).Msgf("\tUnhandled action received: %s. Discarding...", action)
-			return
-		}
+	if id := cs.GetApp().GetID(); id != int64(b.appID) {
+		log.Ctx(ctx).Debug().Str("SHA", shasString).Msg("CheckSuiteEvent received")
+		log.Ctx(ctx).Debug().Str("SHA", shasString).Msgf("\tEvent App ID of %d does not match Bot's App ID of %d", id, b.appID)
+		return
+	}
 
-		log.Info().Str("SHA", shasString).Msg("CheckSuiteEvent received")
+	if action := event.GetAction(); !lib.Contains(checkSuiteRelevantActions, action) {
+		log.Ctx(ctx).Debug().Str("SHA", shasString).Msg("CheckSuiteEvent received")
+		log.Ctx(ctx).Debug().Str("SHA", shasString).Msgf("\tUnhandled action received: %s. Discarding...", action)
+		return
+	}
 
-		r := event.GetRepo()
-		gClient := b.client.CreateClient(int(i.GetID())) // truncating
-		ctx := context.Background()
+	log.Ctx(ctx).Info().Str("SHA", shasString).Msg("CheckSuiteEvent received")
 
-		for _, pr := range cs.PullRequests {
-			b.createCheckRun(ctx, pr, r, gClient)
-		}
-	case *github.CheckRunEvent:
-		i := event.GetInstallation()
-		cr := event.GetCheckRun()
+	r := event.GetRepo()
+	prs, err := b.pullRequestsFor(ctx, cs.PullRequests, cs.GetHeadSHA(), r, ghc)
+	if err != nil {
+		log.Ctx(ctx).Error().Str("SHA", cs.GetHeadSHA()).Err(err).Msg("Failed to find pull requests")
+		return
+	}
 
-		var shas strings.Builder
-		for _, pr := range cr.PullRequests {
-			fmt.Fprintf(&shas, "%s ", pr.GetHead().GetSHA())
-		}
-		shasString := strings.TrimSpace(shas.String())
+	for _, pr := range prs {
+		b.createCheckRun(ctx, pr, r, ghc)
+	}
+}
 
-		if id := cr.GetApp().GetID(); id != int64(b.appID) {
-			log.Debug().Str("SHA", shasString).Msg("CheckRun received")
-			log.Debug().Str("SHA", shasString).Msgf("Event App ID of %d does not match Bot's App ID of %d", id, b.appID)
-			return
-		}
+// handleCheckRun reruns one of the app's check runs, or handles an action requested on it
+func (b *Bot) handleCheckRun(ctx context.Context, event *github.CheckRunEvent, ghc *github.Client) {
+	cr := event.GetCheckRun()
 
-		if action := event.GetAction(); !lib.Contains(checkRunRelevantActions, action) {
-			log.Debug().Str("SHA", shasString).Msg("CheckRun received")
-			log.Debug().Str("SHA", shasString).Msgf("Unhandled action received: %s. Discarding...", action)
-			return
-		}
+	var shas strings.Builder
+	for _, pr := range cr.PullRequests {
+		fmt.Fprintf(&shas, "%s ", pr.GetHead().GetSHA())
+	}
+	shasString := strings.TrimSpace(shas.String())
+
+	if id := cr.GetApp().GetID(); id != int64(b.appID) {
+		log.Ctx(ctx).Debug().Str("SHA", shasString).Msg("CheckRun received")
+		log.Ctx(ctx).Debug().Str("SHA", shasString).Msgf("Event App ID of %d does not match Bot's App ID of %d", id, b.appID)
+		return
+	}
+
+	if action := event.GetAction(); !lib.Contains(checkRunRelevantActions, action) {
+		log.Ctx(ctx).Debug().Str("SHA", shasString).Msg("CheckRun received")
+		log.Ctx(ctx).Debug().Str("SHA", shasString).Msgf("Unhandled action received: %s. Discarding...", action)
+		return
+	}
+
+	log.Ctx(ctx).Info().Str("SHA", shasString).Msg("CheckRun received")
 
-		log.Info().Str("SHA", shasString).Msg("CheckRun received")
+	r := event.GetRepo()
+	switch cr.GetName() {
+	case b.checkName + auditCheckSuffix:
+		b.runAudit(ctx, r.GetOwner().GetLogin(), ghc)
+		return
+	case b.checkName + releaseCheckSuffix:
+		b.recheckRelease(ctx, cr, r, ghc)
+		return
+	}
 
-		r := event.GetRepo()
-		gClient := b.client.CreateClient(int(i.GetID())) // truncating
-		ctx := context.Background()
+	prs, err := b.pullRequestsFor(ctx, cr.PullRequests, cr.GetHeadSHA(), r, ghc)
+	if err != nil {
+		log.Ctx(ctx).Error().Str("SHA", cr.GetHeadSHA()).Err(err).Msg("Failed to find pull requests")
+		return
+	}
 
-		for _, pr := range cr.PullRequests {
-			b.createCheckRun(ctx, pr, r, gClient)
+	for _, pr := range prs {
+		if event.GetAction() == "requested_action" {
+			identifier := event.GetRequestedAction().Identifier
+			b.handleRequestedAction(ctx, identifier, event.GetSender().GetLogin(), cr, pr, r, ghc)
+			continue
 		}
-	case *github.PullRequestEvent:
-		pr := event.GetPullRequest()
-		headSHA := pr.GetHead().GetSHA()
+		b.createCheckRun(ctx, pr, r, ghc)
+	}
+}
+
+// handlePullRequest checks a pull request as it is opened or pushed to
+func (b *Bot) handlePullRequest(ctx context.Context, event *github.PullRequestEvent, ghc *github.Client) {
+	pr := event.GetPullRequest()
+	headSHA := pr.GetHead().GetSHA()
+
+	if action := event.GetAction(); !lib.Contains(pullRequestRelevantActions, action) {
+		log.Ctx(ctx).Debug().Str("SHA", headSHA).Msgf("PullRequestEvent received")
+		log.Ctx(ctx).Debug().Str("SHA", headSHA).Msgf("Unhandled action received: %s. Discarding...", action)
+		return
+	}
+
+	log.Ctx(ctx).Info().Str("SHA", headSHA).Msgf("PullRequestEvent received")
+
+	if !b.firstCheck(event.GetRepo(), pr.GetNumber(), headSHA, event.GetAction()) {
+		log.Ctx(ctx).Info().Str("SHA", headSHA).Msg("Pull request was already checked at this commit. Discarding...")
+		return
+	}
+
+	b.createCheckRun(ctx, pr, event.GetRepo(), ghc)
+}
+
+// handleIssueComment runs the commands in a comment on a pull request
+func (b *Bot) handleIssueComment(ctx context.Context, event *github.IssueCommentEvent, ghc *github.Client) {
+	issue := event.GetIssue()
 
-		i := event.GetInstallation()
+	if action := event.GetAction(); !lib.Contains(issueCommentRelevantActions, action) || !issue.IsPullRequest() {
+		log.Ctx(ctx).Debug().Msgf("IssueCommentEvent received")
+		log.Ctx(ctx).Debug().Msgf("Unhandled action received: %s. Discarding...", action)
+		return
+	}
 
-		if action := event.GetAction(); !lib.Contains(pullRequestRelevantActions, action) {
-			log.Debug().Str("SHA", headSHA).Msgf("PullRequestEvent received")
-			log.Debug().Str("SHA", headSHA).Msgf("Unhandled action received: %s. Discarding...", action)
-			return
+	body := event.GetComment().GetBody()
+	cmds := parseCommands(body)
+	if len(cmds) == 0 || !b.acceptsCommands(ctx, event.GetSender(), body) {
+		return
+	}
+
+	log.Ctx(ctx).Info().Int("PR", issue.GetNumber()).Msg("IssueCommentEvent received")
+
+	b.handleCommands(ctx, cmds, event, ghc)
+}
+
+// handleInstallation onboards the repos of a new installation
+func (b *Bot) handleInstallation(ctx context.Context, event *github.InstallationEvent, ghc *github.Client) {
+	if action := event.GetAction(); !b.onboarding || action != "created" {
+		log.Ctx(ctx).Debug().Msgf("InstallationEvent received")
+		log.Ctx(ctx).Debug().Msgf("Unhandled action received: %s. Discarding...", action)
+		return
+	}
+
+	i := event.GetInstallation()
+	log.Ctx(ctx).Info().Int64("Installation", i.GetID()).Msg("InstallationEvent received")
+
+	b.onboardRepos(ctx, i.GetAccount().GetLogin(), event.Repositories, ghc)
+}
+
+// handleInstallationRepositories onboards the repos added to an installation
+func (b *Bot) handleInstallationRepositories(ctx context.Context, event *github.InstallationRepositoriesEvent, ghc *github.Client) {
+	if action := event.GetAction(); !b.onboarding || action != "added" {
+		log.Ctx(ctx).Debug().Msgf("InstallationRepositoriesEvent received")
+		log.Ctx(ctx).Debug().Msgf("Unhandled action received: %s. Discarding...", action)
+		return
+	}
+
+	i := event.GetInstallation()
+	log.Ctx(ctx).Info().Int64("Installation", i.GetID()).Msg("InstallationRepositoriesEvent received")
+
+	b.onboardRepos(ctx, i.GetAccount().GetLogin(), event.RepositoriesAdded, ghc)
+}
+
+// handleIssues checks an issue as it is opened or edited
+func (b *Bot) handleIssues(ctx context.Context, event *github.IssuesEvent, ghc *github.Client) {
+	issue := event.GetIssue()
+
+	if action := event.GetAction(); !lib.Contains(issueRelevantActions, action) {
+		log.Ctx(ctx).Debug().Int("Issue", issue.GetNumber()).Msgf("IssuesEvent received")
+		log.Ctx(ctx).Debug().Int("Issue", issue.GetNumber()).Msgf("Unhandled action received: %s. Discarding...", action)
+		return
+	}
+
+	// The bot opens issues itself, e.x. to report flagged terms in wiki pages
+	if b.isBot(ctx, event.GetSender()) {
+		log.Ctx(ctx).Debug().Int("Issue", issue.GetNumber()).Msg("Sent by the bot. Discarding...")
+		return
+	}
+
+	log.Ctx(ctx).Info().Int("Issue", issue.GetNumber()).Msgf("IssuesEvent received")
+
+	b.checkIssue(ctx, issue, event.GetChanges(), event.GetRepo(), ghc)
+}
+
+// handleDiscussion checks a discussion as it is created or edited
+func (b *Bot) handleDiscussion(ctx context.Context, event *gh.DiscussionEvent, ghc *github.Client) {
+	d := event.GetDiscussion()
+
+	if action := event.GetAction(); !lib.Contains(discussionRelevantActions, action) {
+		log.Ctx(ctx).Debug().Int("Discussion", d.GetNumber()).Msgf("DiscussionEvent received")
+		log.Ctx(ctx).Debug().Int("Discussion", d.GetNumber()).Msgf("Unhandled action received: %s. Discarding...", action)
+		return
+	}
+
+	if b.isBot(ctx, event.GetSender()) {
+		log.Ctx(ctx).Debug().Int("Discussion", d.GetNumber()).Msg("Sent by the bot. Discarding...")
+		return
+	}
+
+	log.Ctx(ctx).Info().Int("Discussion", d.GetNumber()).Msgf("DiscussionEvent received")
+
+	b.checkDiscussion(ctx, d, event.GetChanges(), event.GetRepo(), ghc)
+}
+
+// handleMergeGroup checks a merge group as it is queued
+func (b *Bot) handleMergeGroup(ctx context.Context, event *gh.MergeGroupEvent, ghc *github.Client) {
+	mg := event.GetMergeGroup()
+	headSHA := mg.GetHeadSHA()
+
+	if action := event.GetAction(); !lib.Contains(mergeGroupRelevantActions, action) {
+		log.Ctx(ctx).Debug().Str("SHA", headSHA).Msgf("MergeGroupEvent received")
+		log.Ctx(ctx).Debug().Str("SHA", headSHA).Msgf("Unhandled action received: %s. Discarding...", action)
+		return
+	}
+
+	log.Ctx(ctx).Info().Str("SHA", headSHA).Msgf("MergeGroupEvent received")
+
+	// Merge groups have no pull request of their own
+	if !b.firstCheck(event.GetRepo(), 0, headSHA, event.GetAction()) {
+		log.Ctx(ctx).Info().Str("SHA", headSHA).Msg("Merge group was already checked. Discarding...")
+		return
+	}
+
+	b.createMergeGroupCheckRun(ctx, mg, event.GetRepo(), ghc)
+}
+
+// handleRelease checks a release as it is published or edited
+func (b *Bot) handleRelease(ctx context.Context, event *github.ReleaseEvent, ghc *github.Client) {
+	release := event.GetRelease()
+
+	if action := event.GetAction(); !lib.Contains(releaseRelevantActions, action) {
+		log.Ctx(ctx).Debug().Str("Tag", release.GetTagName()).Msgf("ReleaseEvent received")
+		log.Ctx(ctx).Debug().Str("Tag", release.GetTagName()).Msgf("Unhandled action received: %s. Discarding...", action)
+		return
+	}
+
+	log.Ctx(ctx).Info().Str("Tag", release.GetTagName()).Msgf("ReleaseEvent received")
+
+	b.checkRelease(ctx, release, event.GetRepo(), ghc)
+}
+
+// handleCreate checks the name of a tag as it is created
+func (b *Bot) handleCreate(ctx context.Context, event *github.CreateEvent, ghc *github.Client) {
+	if refType := event.GetRefType(); refType != "tag" {
+		log.Ctx(ctx).Debug().Str("Ref", event.GetRef()).Msgf("CreateEvent received")
+		log.Ctx(ctx).Debug().Str("Ref", event.GetRef()).Msgf("Unhandled ref type received: %s. Discarding...", refType)
+		return
+	}
+
+	log.Ctx(ctx).Info().Str("Tag", event.GetRef()).Msgf("CreateEvent received")
+
+	b.checkTag(ctx, event.GetRef(), event.GetRepo(), ghc)
+}
+
+// handleRepository audits the metadata of a repository as it is created or changed
+func (b *Bot) handleRepository(ctx context.Context, event *github.RepositoryEvent, ghc *github.Client) {
+	r := event.GetRepo()
+
+	if action := event.GetAction(); !lib.Contains(repositoryRelevantActions, action) {
+		log.Ctx(ctx).Debug().Str("Repo", r.GetFullName()).Msgf("RepositoryEvent received")
+		log.Ctx(ctx).Debug().Str("Repo", r.GetFullName()).Msgf("Unhandled action received: %s. Discarding...", action)
+		return
+	}
+
+	log.Ctx(ctx).Info().Str("Repo", r.GetFullName()).Msgf("RepositoryEvent received")
+
+	b.updateAudit(ctx, r, ghc)
+}
+
+// firstCheck records that a pull request is being checked automatically at a commit for an event action, returning
+// false if it already was recently, as several deliveries can be sent for the same event. Pull requests sharing a head
+// commit are checked separately. Checks asked for explicitly, through reruns and commands, skip this.
+func (b *Bot) firstCheck(r *github.Repository, number int, sha, action string) bool {
+	return b.checked.Add(fmt.Sprintf("%s#%d@%s:%s", r.GetFullName(), number, sha, action))
+}
+
+// pullRequestsFor returns the pull requests a check suite or run belongs to. GitHub leaves them out of the event when
+// the head branch is in a fork, in which case the open pull requests with the commit at their head are looked up.
+func (b *Bot) pullRequestsFor(ctx context.Context, prs []*github.PullRequest, headSHA string, r *github.Repository, ghc *github.Client) ([]*github.PullRequest, error) {
+	if len(prs) > 0 {
+		return prs, nil
+	}
+
+	opts := &github.PullRequestListOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		res, resp, err := ghc.PullRequests.ListPullRequestsWithCommit(ctx, r.GetOwner().GetLogin(), r.GetName(), headSHA, opts)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list pull requests for %s: %s", headSHA, err)
 		}
 
-		log.Info().Str("SHA", headSHA).Msgf("PullRequestEvent received")
+		for _, pr := range res {
+			if pr.GetState() == "open" && pr.GetHead().GetSHA() == headSHA {
+				prs = append(prs, pr)
+			}
+		}
 
-		gClient := b.client.CreateClient(int(i.GetID())) // truncating
-		ctx := context.Background()
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
 
-		b.createCheckRun(ctx, pr, event.GetRepo(), gClient)
-	default:
-		log.Debug().Msgf("Unhandled event received: %s. Discarding...", reflect.TypeOf(event).Elem().Name())
+	if len(prs) == 0 {
+		log.Ctx(ctx).Info().Str("SHA", headSHA).Msg("No open pull request has this commit at its head. Discarding...")
 	}
+	return prs, nil
 }
 
 func (b *Bot) createCheckRun(ctx context.Context, pr *github.PullRequest, r *github.Repository, ghc *github.Client) {
+	if b.tokenMode() {
+		b.createStatus(ctx, pr, r, ghc)
+		return
+	}
+
 	headSHA := pr.GetHead().GetSHA()
 
-	log.Info().Str("SHA", headSHA).Msg("Creating CheckRun...")
-	annotations, err := b.createAnnotations(ctx, pr, r, ghc)
+	log.Ctx(ctx).Info().Str("SHA", headSHA).Msg("Creating CheckRun...")
+
+	cr, err := b.startCheckRun(ctx, r, headSHA, ghc)
 	if err != nil {
-		log.Error().Str("SHA", headSHA).Err(err).Msg("Failed to create annotations")
+		log.Ctx(ctx).Error().Str("SHA", headSHA).Err(err).Msgf("Failed to POST CheckRun")
 		return
 	}
 
-	cro := github.CreateCheckRunOptions{
+	rc, oc, err := getConfigs(ctx, r, headSHA, ghc)
+	if err != nil {
+		b.failCheckRun(ctx, cr, r, ghc, err)
+		return
+	}
+
+	sc, err := b.scanPullRequest(ctx, pr, r, ghc, rc)
+	if err != nil {
+		b.failCheckRun(ctx, cr, r, ghc, err)
+		return
+	}
+
+	ex, err := b.getExemptions(ctx, pr, r, ghc)
+	if err != nil {
+		b.failCheckRun(ctx, cr, r, ghc, err)
+		return
+	}
+	findings := b.filter(sc.findings, ex)
+
+	cro := b.checkRunResult(findings, rc, oc, ex, fmt.Sprintf("%s/pull/%d", r.GetHTMLURL(), pr.GetNumber()))
+	cr = b.completeCheckRun(ctx, cr, r, ghc, cro)
+
+	b.reportOnPullRequest(ctx, pr, r, ghc, rc, findings, sc.removed, cr, ex)
+}
+
+// reportOnPullRequest posts the results of a check on the pull request itself, through suggested changes, labels and
+// the summary comment, as configured for the repo
+func (b *Bot) reportOnPullRequest(ctx context.Context, pr *github.PullRequest, r *github.Repository, ghc *github.Client, rc *config.RepoConfig, findings []finding, removed int, cr *github.CheckRun, ex *exemptions) {
+	headSHA := pr.GetHead().GetSHA()
+
+	// Without check run annotations, review comments are the only way to point at the lines with findings
+	if (b.suggestChangesFor(rc) || b.tokenMode()) && !ex.pr {
+		if err := b.createReview(ctx, pr, r, ghc, findings, b.tokenMode()); err != nil {
+			log.Ctx(ctx).Error().Str("SHA", headSHA).Err(err).Msg("Failed to POST suggested changes")
+		}
+	}
+
+	if err := b.updateLabels(ctx, pr, r, ghc, rc, findings, len(findings) > 0 && !ex.pr); err != nil {
+		log.Ctx(ctx).Error().Str("SHA", headSHA).Err(err).Msg("Failed to update labels")
+	}
+
+	if b.summaryCommentFor(rc) {
+		if err := b.updateSummaryComment(ctx, pr, r, ghc, findings, removed, cr, ex); err != nil {
+			log.Ctx(ctx).Error().Str("SHA", headSHA).Err(err).Msg("Failed to update summary comment")
+		}
+	}
+
+	if b.uploadSARIFFor(rc) {
+		uploaded := findings
+		if ex.pr {
+			uploaded = nil
+		}
+		if err := b.uploadFindings(ctx, pr, r, ghc, uploaded); err != nil {
+			log.Ctx(ctx).Error().Str("SHA", headSHA).Err(err).Msg("Failed to upload SARIF")
+		}
+	}
+}
+
+// startCheckRun marks the bot's check run on a commit as in progress, so the check shows as running while the bot
+// works. The bot's existing check run for the commit is reused, and a new one is only created if there is none.
+func (b *Bot) startCheckRun(ctx context.Context, r *github.Repository, headSHA string, ghc *github.Client) (*github.CheckRun, error) {
+	owner, name := r.GetOwner().GetLogin(), r.GetName()
+
+	existing, err := b.findCheckRun(ctx, r, headSHA, b.checkName, ghc)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil {
+		cr, resp, err := ghc.Checks.UpdateCheckRun(ctx, owner, name, existing.GetID(), github.UpdateCheckRunOptions{
+			Name:   b.checkName,
+			Status: github.String("in_progress"),
+		})
+		if err != nil || resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("Failed to update CheckRun %d for %s: %s", existing.GetID(), headSHA, err)
+		}
+		return cr, nil
+	}
+
+	cr, resp, err := ghc.Checks.CreateCheckRun(ctx, owner, name, github.CreateCheckRunOptions{
+		Name:      b.checkName,
+		HeadSHA:   headSHA,
+		Status:    github.String("in_progress"),
+		StartedAt: &github.Timestamp{Time: time.Now()},
+	})
+	if err != nil || resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("Failed to create CheckRun for %s: %s", headSHA, err)
+	}
+	return cr, nil
+}
+
+// findCheckRun returns the bot's latest check run with the passed in name for a commit, or nil if there is none
+func (b *Bot) findCheckRun(ctx context.Context, r *github.Repository, headSHA, name string, ghc *github.Client) (*github.CheckRun, error) {
+	opts := &github.ListCheckRunsOptions{
+		CheckName:   github.String(name),
+		Filter:      github.String("latest"),
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		res, resp, err := ghc.Checks.ListCheckRunsForRef(ctx, r.GetOwner().GetLogin(), r.GetName(), headSHA, opts)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list CheckRuns for %s: %s", headSHA, err)
+		}
+
+		// Other apps may report checks with the same name
+		for _, cr := range res.CheckRuns {
+			if cr.GetApp().GetID() == int64(b.appID) {
+				return cr, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// checkRunResult builds the options completing a check run with the passed in findings. The details URL is only
+// used by the action required mode, which needs a page for the user to go to.
+func (b *Bot) checkRunResult(findings []finding, rc *config.RepoConfig, oc *config.OrgConfig, ex *exemptions, detailsURL string) github.UpdateCheckRunOptions {
+	annotations := b.createAnnotations(findings)
+
+	cro := github.UpdateCheckRunOptions{
 		Name:        b.checkName,
-		HeadSHA:     headSHA,
 		Status:      github.String("completed"),
 		CompletedAt: &github.Timestamp{Time: time.Now()},
 		Output: &github.CheckRunOutput{
@@ -201,27 +711,177 @@ func (b *Bot) createCheckRun(ctx context.Context, pr *github.PullRequest, r *git
 		},
 	}
 	// presence of annotations signals there is usage of flagged terms
-	if len(annotations) > 0 {
-		cro.Conclusion = github.String(checkFailureConclusion)
+	if len(annotations) > 0 && ex.pr {
+		cro.Conclusion = github.String(checkSuccessConclusion)
+		cro.Output.Summary = github.String(fmt.Sprintf("%s\n\nIgnored for this pull request by @%s", b.checkFailureSummary, ex.prBy))
+	} else if len(annotations) > 0 {
+		mode := b.enforcementMode(rc, oc)
+		cro.Conclusion = github.String(modeConclusions[mode])
 		cro.Output.Summary = github.String(b.checkFailureSummary)
+		cro.Actions = b.checkRunActions(findings)
+		if mode == config.ActionRequiredMode {
+			cro.DetailsURL = github.String(detailsURL)
+		}
 	} else {
 		cro.Conclusion = github.String(checkSuccessConclusion)
 		cro.Output.Summary = github.String(b.checkSuccessSummary)
 	}
 
-	_, resp, err := ghc.Checks.CreateCheckRun(ctx, r.GetOwner().GetLogin(), r.GetName(), cro)
-	if code := resp.StatusCode; err != nil || (code < 200 || code > 299) {
-		log.Error().Str("SHA", headSHA).Err(err).Msgf("Failed to POST CheckRun")
-	} else {
-		log.Info().Str("SHA", headSHA).Msgf("Successfully created CheckRun")
+	return cro
+}
+
+// completeCheckRun updates a check run with its result, returning the updated check run. GitHub appends annotations
+// on every update, so annotations a reused check run already has are not sent again. As annotations can't be removed
+// either, a reused check run with annotations that are no longer found is replaced by a new one.
+func (b *Bot) completeCheckRun(ctx context.Context, cr *github.CheckRun, r *github.Repository, ghc *github.Client, cro github.UpdateCheckRunOptions) *github.CheckRun {
+	headSHA := cr.GetHeadSHA()
+
+	if cr.GetOutput().GetAnnotationsCount() > 0 {
+		existing, err := existingAnnotations(ctx, cr, r, ghc)
+		if err != nil {
+			log.Ctx(ctx).Error().Str("SHA", headSHA).Err(err).Msg("Failed to list existing annotations")
+			return b.replaceCheckRun(ctx, cr, r, ghc, cro)
+		}
+
+		found := make(map[string]struct{})
+		for _, a := range cro.Output.Annotations {
+			found[annotationKey(a)] = struct{}{}
+		}
+		for k := range existing {
+			if !lib.Contains(found, k) {
+				return b.replaceCheckRun(ctx, cr, r, ghc, cro)
+			}
+		}
+
+		var annotations []*github.CheckRunAnnotation
+		for _, a := range cro.Output.Annotations {
+			if !lib.Contains(existing, annotationKey(a)) {
+				annotations = append(annotations, a)
+			}
+		}
+		cro.Output.Annotations = annotations
 	}
+
+	updated, resp, err := ghc.Checks.UpdateCheckRun(ctx, r.GetOwner().GetLogin(), r.GetName(), cr.GetID(), cro)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		log.Ctx(ctx).Error().Str("SHA", headSHA).Err(err).Msgf("Failed to complete CheckRun")
+		return cr
+	}
+
+	log.Ctx(ctx).Info().Str("SHA", headSHA).Msgf("Successfully created CheckRun")
+	return updated
 }
 
-func (b *Bot) createAnnotations(ctx context.Context, pr *github.PullRequest, r *github.Repository, ghc *github.Client) ([]*github.CheckRunAnnotation, error) {
-	headSHA := pr.GetHead().GetSHA()
+// replaceCheckRun creates a new check run with the result a reused check run should have been completed with, and
+// completes the old one as neutral. The new check run is the latest for the commit, so it is the one GitHub shows.
+func (b *Bot) replaceCheckRun(ctx context.Context, old *github.CheckRun, r *github.Repository, ghc *github.Client, cro github.UpdateCheckRunOptions) *github.CheckRun {
+	owner, name, headSHA := r.GetOwner().GetLogin(), r.GetName(), old.GetHeadSHA()
+
+	cr, resp, err := ghc.Checks.CreateCheckRun(ctx, owner, name, github.CreateCheckRunOptions{
+		Name:        cro.Name,
+		HeadSHA:     headSHA,
+		DetailsURL:  cro.DetailsURL,
+		Status:      cro.Status,
+		Conclusion:  cro.Conclusion,
+		StartedAt:   old.StartedAt,
+		CompletedAt: cro.CompletedAt,
+		Output:      cro.Output,
+		Actions:     cro.Actions,
+	})
+	if err != nil || resp.StatusCode != http.StatusCreated {
+		log.Ctx(ctx).Error().Str("SHA", headSHA).Err(err).Msgf("Failed to create CheckRun")
+		return old
+	}
+
+	_, resp, err = ghc.Checks.UpdateCheckRun(ctx, owner, name, old.GetID(), github.UpdateCheckRunOptions{
+		Name:        b.checkName,
+		Status:      github.String("completed"),
+		Conclusion:  github.String("neutral"),
+		CompletedAt: &github.Timestamp{Time: time.Now()},
+		Output: &github.CheckRunOutput{
+			Title:   github.String(b.checkName),
+			Summary: github.String("Replaced by a newer run of this check."),
+		},
+	})
+	if err != nil || resp.StatusCode != http.StatusOK {
+		log.Ctx(ctx).Error().Str("SHA", headSHA).Err(err).Msgf("Failed to complete replaced CheckRun %d", old.GetID())
+	}
 
-	// Get repository configuration
-	rc := config.GetRepoConfig(ctx, r, headSHA, ghc)
+	log.Ctx(ctx).Info().Str("SHA", headSHA).Msgf("Successfully replaced CheckRun %d", old.GetID())
+	return cr
+}
+
+// existingAnnotations returns the keys of the annotations a check run already has
+func existingAnnotations(ctx context.Context, cr *github.CheckRun, r *github.Repository, ghc *github.Client) (map[string]struct{}, error) {
+	existing := make(map[string]struct{})
+
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		annotations, resp, err := ghc.Checks.ListCheckRunAnnotations(ctx, r.GetOwner().GetLogin(), r.GetName(), cr.GetID(), opts)
+		if err != nil {
+			return existing, fmt.Errorf("Failed to list annotations of CheckRun %d: %s", cr.GetID(), err)
+		}
+
+		for _, a := range annotations {
+			existing[annotationKey(a)] = struct{}{}
+		}
+
+		if resp.NextPage == 0 {
+			return existing, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+func annotationKey(a *github.CheckRunAnnotation) string {
+	return fmt.Sprintf("%s:%d:%s", a.GetPath(), a.GetStartLine(), a.GetMessage())
+}
+
+// getConfigs retrieves the configuration of a repository at a commit, along with the configuration of its organization
+func getConfigs(ctx context.Context, r *github.Repository, headSHA string, ghc *github.Client) (*config.RepoConfig, *config.OrgConfig, error) {
+	rc, err := config.GetRepoConfig(ctx, r, headSHA, ghc)
+	if err != nil {
+		return nil, nil, err
+	}
+	oc, err := config.GetOrgConfig(ctx, r.GetOwner().GetLogin(), ghc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rc, oc, nil
+}
+
+// failCheckRun completes a check run that could not finish, showing the error that stopped it. Runs stopped by their
+// context being cancelled are completed as cancelled, any other error fails them.
+func (b *Bot) failCheckRun(ctx context.Context, cr *github.CheckRun, r *github.Repository, ghc *github.Client, cause error) {
+	headSHA := cr.GetHeadSHA()
+	log.Ctx(ctx).Error().Str("SHA", headSHA).Err(cause).Msg("Failed to check for flagged terms")
+
+	conclusion, summary := "failure", fmt.Sprintf("%s could not check this commit.", b.checkName)
+	if ctx.Err() != nil {
+		conclusion, summary = "cancelled", fmt.Sprintf("%s was cancelled before it could finish.", b.checkName)
+		// The original context is done, but the check run still has to be completed
+		ctx = context.Background()
+	}
+
+	_, resp, err := ghc.Checks.UpdateCheckRun(ctx, r.GetOwner().GetLogin(), r.GetName(), cr.GetID(), github.UpdateCheckRunOptions{
+		Name:        b.checkName,
+		Status:      github.String("completed"),
+		Conclusion:  github.String(conclusion),
+		CompletedAt: &github.Timestamp{Time: time.Now()},
+		Output: &github.CheckRunOutput{
+			Title:   github.String(b.checkName),
+			Summary: github.String(summary),
+			Text:    github.String(fmt.Sprintf("```\n%s\n```", cause)),
+		},
+	})
+	if err != nil || resp.StatusCode != http.StatusOK {
+		log.Ctx(ctx).Error().Str("SHA", headSHA).Err(err).Msgf("Failed to complete CheckRun")
+	}
+}
+
+// scanPullRequest fetches the diff of the pull request and checks it for flagged terms
+func (b *Bot) scanPullRequest(ctx context.Context, pr *github.PullRequest, r *github.Repository, ghc *github.Client, rc *config.RepoConfig) (*scan, error) {
+	headSHA := pr.GetHead().GetSHA()
 
 	// Get PR diff
 	diff, resp, err := ghc.PullRequests.GetRaw( // TODO: refactor to move methods making requests to Client?
@@ -233,56 +893,148 @@ func (b *Bot) createAnnotations(ctx context.Context, pr *github.PullRequest, r *
 	)
 	if err != nil || resp.StatusCode != http.StatusOK {
 		e := fmt.Errorf("Failed to get diff for %s: %s", headSHA, err)
-		return []*github.CheckRunAnnotation{}, e
+		return nil, e
 	}
+
+	return b.scanDiff(diff, headSHA, rc)
+}
+
+// scanDiff parses a raw diff and checks it for flagged terms
+func (b *Bot) scanDiff(diff string, headSHA string, rc *config.RepoConfig) (*scan, error) {
 	parsedDiff, err := diffparser.Parse(diff)
 	if err != nil {
 		e := fmt.Errorf("Failed to parse diff for %s: %s", headSHA, err)
-		return []*github.CheckRunAnnotation{}, e
+		return nil, e
 	}
 
-	re, _ := regexp.Compile(strings.Join(b.termList, "|"))
+	return b.findTerms(parsedDiff, rc), nil
+}
+
+func (b *Bot) createAnnotations(findings []finding) []*github.CheckRunAnnotation {
 	var annotations = []*github.CheckRunAnnotation{}
+	for _, f := range findings {
+		annotations = append(annotations, b.createAnnotation(f))
+	}
+	return annotations
+}
+
+// scan holds the results of checking a diff for flagged terms
+type scan struct {
+	findings []finding
+	removed  int // number of flagged terms used on removed lines
+}
+
+// finding holds the flagged terms used on a single added line of a file, along with the line's raw content and the
+// byte ranges of the content the terms were found in
+type finding struct {
+	path    string
+	line    int
+	content string
+	terms   []string
+	spans   [][]int
+}
+
+// findTerms runs the file's extractor over the lines added to each file in the diff, returning a finding for every
+// line using flagged terms. Flagged terms on removed lines are only counted.
+func (b *Bot) findTerms(parsedDiff *diffparser.Diff, rc *config.RepoConfig) *scan {
+	s := scan{}
 
 	for _, f := range parsedDiff.Files {
+		var added, removed []extract.Line
+		for _, h := range f.Hunks {
+			for _, l := range h.NewRange.Lines {
+				if l.Mode == diffparser.ADDED {
+					added = append(added, extract.Line{Number: l.Number, Content: l.Content})
+				}
+			}
+			for _, l := range h.OrigRange.Lines {
+				if l.Mode == diffparser.REMOVED {
+					removed = append(removed, extract.Line{Number: l.Number, Content: l.Content})
+				}
+			}
+		}
+
 		// Skip over any files listed in `ignore`
-		if ignoredByRepo(rc, f.NewName) {
-			continue
+		if f.Mode != diffparser.DELETED && !ignoredByRepo(rc, f.NewName) {
+			s.findings = append(s.findings, b.matchLines(f.NewName, added)...)
+		}
+		if !ignoredByRepo(rc, f.OrigName) {
+			for _, rf := range b.matchLines(f.OrigName, removed) {
+				s.removed += len(rf.terms)
+			}
 		}
+	}
 
-		for _, h := range f.Hunks {
-			if f.Mode == diffparser.DELETED {
-				continue
+	return &s
+}
+
+// matchLines returns a finding for every one of the passed in lines of a file using flagged terms
+func (b *Bot) matchLines(path string, lines []extract.Line) []finding {
+	var findings []finding
+
+	contents := make(map[int]string)
+	for _, l := range lines {
+		contents[l.Number] = l.Content
+	}
+
+	// Segments are grouped back by the line they came from so each line gets a single finding
+	var matches []string
+	var spans [][]int
+	segments := extract.ForFile(path).Extract(lines)
+	for i, s := range segments {
+		matches = append(matches, b.termPattern.FindAllString(s.Text, -1)...)
+
+		// Terms are looked for again in the raw part of the line the text came from, for fixes to replace. Terms
+		// broken up by escaping are only reported.
+		if content := contents[s.Line]; s.Start < s.End && s.End <= len(content) {
+			for _, loc := range b.termPattern.FindAllStringIndex(content[s.Start:s.End], -1) {
+				spans = append(spans, []int{s.Start + loc[0], s.Start + loc[1]})
 			}
+		}
 
-			adds := h.NewRange
-			for _, l := range adds.Lines {
-				if l.Mode == diffparser.ADDED {
-					if matches := lib.Unique(re.FindAllString(l.Content, -1)); len(matches) > 0 {
-						annotations = append(annotations, b.createAnnotation(f, l, matches))
-					}
-				}
+		if i == len(segments)-1 || segments[i+1].Line != s.Line {
+			if m := lib.Unique(matches); len(m) > 0 {
+				findings = append(findings, finding{path: path, line: s.Line, content: contents[s.Line], terms: m, spans: spans})
 			}
+			matches, spans = nil, nil
 		}
 	}
 
-	return annotations, nil
+	return findings
 }
 
-func (b *Bot) createAnnotation(f *diffparser.DiffFile, l *diffparser.DiffLine, m []string) (a *github.CheckRunAnnotation) {
-	msg := fmt.Sprintf(b.annotationBody, strings.Join(m, ", ")) // Expects %s format string in body
-	msg = strings.Split(msg, "%!")[0]                           // Remove formatting error if user doesn't provide format string in body
-
+func (b *Bot) createAnnotation(f finding) (a *github.CheckRunAnnotation) {
 	return &github.CheckRunAnnotation{
-		Path:            github.String(f.NewName),
-		StartLine:       github.Int(l.Number),
-		EndLine:         github.Int(l.Number),
+		Path:            github.String(f.path),
+		StartLine:       github.Int(f.line),
+		EndLine:         github.Int(f.line),
 		AnnotationLevel: github.String(checkRunAnnotationLevel),
-		Message:         github.String(msg),
+		Message:         github.String(b.annotationMessage(f.terms)),
 		Title:           github.String(b.annotationTitle),
 	}
 }
 
+// lookupTerm returns the configured term whose source is the passed in string, falling back to the first term
+// matching it
+func (b *Bot) lookupTerm(s string) (term, bool) {
+	for _, t := range b.terms {
+		if t.source == s {
+			return t, true
+		}
+	}
+	for _, t := range b.terms {
+		if t.pattern.MatchString(s) {
+			return t, true
+		}
+	}
+	return term{}, false
+}
+
+func (b *Bot) annotationMessage(terms []string) string {
+	msg := fmt.Sprintf(b.annotationBody, strings.Join(terms, ", ")) // Expects %s format string in body
+	return strings.Split(msg, "%!")[0]                              // Remove formatting error if user doesn't provide format string in body
+}
+
 func ignoredByRepo(rc *config.RepoConfig, filename string) bool {
 	if ignorePatterns := rc.Ignore; ignorePatterns != nil {
 		ignoreMatcher := ignore.CompileIgnoreLines(ignorePatterns...)
@@ -0,0 +1,308 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/zendesk/term-check/internal/githubtest"
+	gh "github.com/zendesk/term-check/pkg/github"
+	"github.com/zendesk/term-check/pkg/lib"
+)
+
+// newFakeChecks starts a fake GitHub API serving the check runs and annotations of commit abc in the test repo
+func newFakeChecks(t *testing.T, checkRuns, annotations string) *githubtest.Server {
+	s := githubtest.NewServer(t)
+	s.Respond("GET /repos/zendesk/term-check/commits/abc/check-runs", http.StatusOK, checkRuns)
+	s.Respond("GET /repos/zendesk/term-check/check-runs/1/annotations", http.StatusOK, annotations)
+	s.Respond("POST /repos/zendesk/term-check/check-runs", http.StatusCreated, `{"id": 2, "head_sha": "abc"}`)
+	s.Respond("PATCH /repos/zendesk/term-check/check-runs/1", http.StatusOK, `{"id": 1, "head_sha": "abc"}`)
+	s.Respond("PATCH /repos/zendesk/term-check/check-runs/2", http.StatusOK, `{"id": 2, "head_sha": "abc"}`)
+	return s
+}
+
+var testRepo = &github.Repository{Name: github.String("term-check"), Owner: &github.User{Login: github.String("zendesk")}}
+
+type startCheckRunTestCase struct {
+	name             string
+	checkRuns        string
+	expectedID       int64
+	expectedRequests []string
+}
+
+func TestStartCheckRun(t *testing.T) {
+	cases := []startCheckRunTestCase{
+		{
+			name:       "CreatesCheckRun",
+			checkRuns:  `{"total_count": 0, "check_runs": []}`,
+			expectedID: 2,
+			expectedRequests: []string{
+				"GET /repos/zendesk/term-check/commits/abc/check-runs",
+				"POST /repos/zendesk/term-check/check-runs",
+			},
+		},
+		{
+			name:       "ReusesOwnCheckRun",
+			checkRuns:  `{"total_count": 2, "check_runs": [{"id": 3, "app": {"id": 7}}, {"id": 1, "app": {"id": 1}}]}`,
+			expectedID: 1,
+			expectedRequests: []string{
+				"GET /repos/zendesk/term-check/commits/abc/check-runs",
+				"PATCH /repos/zendesk/term-check/check-runs/1",
+			},
+		},
+		{
+			name:       "IgnoresOtherAppsCheckRuns",
+			checkRuns:  `{"total_count": 1, "check_runs": [{"id": 3, "app": {"id": 7}}]}`,
+			expectedID: 2,
+			expectedRequests: []string{
+				"GET /repos/zendesk/term-check/commits/abc/check-runs",
+				"POST /repos/zendesk/term-check/check-runs",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newFakeChecks(t, tc.checkRuns, "[]")
+
+			b := &Bot{appID: 1, checkName: "term-check"}
+			cr, err := b.startCheckRun(context.Background(), testRepo, "abc", s.Client)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expectedID, cr.GetID())
+			}
+			requests := s.Requests()
+			assert.Equal(t, tc.expectedRequests, s.Routes())
+			assert.Equal(t, "term-check", requests[len(requests)-1].Body["name"])
+			// The run shows as running until the check completes it
+			assert.Equal(t, "in_progress", requests[len(requests)-1].Body["status"])
+		})
+	}
+}
+
+type completeCheckRunTestCase struct {
+	name                string
+	annotations         string
+	expectedID          int64
+	expectedRequests    []string
+	expectedAnnotations int
+}
+
+func TestCompleteCheckRun(t *testing.T) {
+	master := `{"path": "README.md", "start_line": 1, "message": "master"}`
+	slave := `{"path": "README.md", "start_line": 2, "message": "slave"}`
+
+	cases := []completeCheckRunTestCase{
+		{
+			name:                "SendsOnlyNewAnnotations",
+			annotations:         "[" + master + "]",
+			expectedID:          1,
+			expectedAnnotations: 1,
+			expectedRequests: []string{
+				"GET /repos/zendesk/term-check/check-runs/1/annotations",
+				"PATCH /repos/zendesk/term-check/check-runs/1",
+			},
+		},
+		{
+			name:                "ReplacesCheckRunWithStaleAnnotations",
+			annotations:         "[" + master + "," + `{"path": "main.go", "start_line": 3, "message": "whitelist"}` + "]",
+			expectedID:          2,
+			expectedAnnotations: 2,
+			expectedRequests: []string{
+				"GET /repos/zendesk/term-check/check-runs/1/annotations",
+				"POST /repos/zendesk/term-check/check-runs",
+				"PATCH /repos/zendesk/term-check/check-runs/1",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newFakeChecks(t, `{"total_count": 0, "check_runs": []}`, tc.annotations)
+
+			var annotations []*github.CheckRunAnnotation
+			if err := json.Unmarshal([]byte("["+master+","+slave+"]"), &annotations); err != nil {
+				t.Fatal(err)
+			}
+			cr := &github.CheckRun{
+				ID:      github.Int64(1),
+				HeadSHA: github.String("abc"),
+				Output:  &github.CheckRunOutput{AnnotationsCount: github.Int(1)},
+			}
+			cro := github.UpdateCheckRunOptions{
+				Name:       "term-check",
+				Status:     github.String("completed"),
+				Conclusion: github.String("neutral"),
+				Output: &github.CheckRunOutput{
+					Title:       github.String("term-check"),
+					Summary:     github.String("Flagged terms found"),
+					Annotations: annotations,
+				},
+			}
+
+			b := &Bot{appID: 1, checkName: "term-check"}
+			updated := b.completeCheckRun(context.Background(), cr, testRepo, s.Client, cro)
+
+			assert.Equal(t, tc.expectedID, updated.GetID())
+			assert.Equal(t, tc.expectedRequests, s.Routes())
+			// The results go to the second request, whether it updates the reused run or creates a new one
+			output := s.Requests()[1].Body["output"].(map[string]interface{})
+			assert.Len(t, output["annotations"], tc.expectedAnnotations)
+		})
+	}
+}
+
+type failCheckRunTestCase struct {
+	name               string
+	cancelled          bool
+	expectedConclusion string
+}
+
+func TestFailCheckRun(t *testing.T) {
+	cases := []failCheckRunTestCase{
+		{name: "Failure", cancelled: false, expectedConclusion: "failure"},
+		{name: "Cancelled", cancelled: true, expectedConclusion: "cancelled"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newFakeChecks(t, `{"total_count": 0, "check_runs": []}`, "[]")
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if tc.cancelled {
+				cancel()
+			} else {
+				defer cancel()
+			}
+
+			b := &Bot{checkName: "term-check"}
+			cr := &github.CheckRun{ID: github.Int64(1), HeadSHA: github.String("abc")}
+			b.failCheckRun(ctx, cr, testRepo, s.Client, errors.New("Failed to get repo config"))
+
+			// The run is completed even when the context it was started with is done
+			body := s.Body("PATCH /repos/zendesk/term-check/check-runs/1")
+			if assert.NotNil(t, body) {
+				assert.Equal(t, "completed", body["status"])
+				assert.Equal(t, tc.expectedConclusion, body["conclusion"])
+				output := body["output"].(map[string]interface{})
+				assert.Contains(t, output["text"], "Failed to get repo config")
+			}
+		})
+	}
+}
+
+func TestPullRequestsForFork(t *testing.T) {
+	s := githubtest.NewServer(t)
+	s.Respond("GET /repos/zendesk/term-check/commits/abc/pulls", http.StatusOK, `[
+		{"number": 1, "state": "open", "head": {"sha": "abc"}},
+		{"number": 2, "state": "closed", "head": {"sha": "abc"}},
+		{"number": 3, "state": "open", "head": {"sha": "def"}}
+	]`)
+	ghc := s.Client
+
+	b := &Bot{}
+	prs, err := b.pullRequestsFor(context.Background(), nil, "abc", testRepo, ghc)
+	if assert.NoError(t, err) && assert.Len(t, prs, 1) {
+		assert.Equal(t, 1, prs[0].GetNumber())
+	}
+
+	// Pull requests sent with the event are used as they are
+	sent := []*github.PullRequest{{Number: github.Int(4)}}
+	prs, err = b.pullRequestsFor(context.Background(), sent, "abc", testRepo, ghc)
+	if assert.NoError(t, err) {
+		assert.Equal(t, sent, prs)
+	}
+}
+
+type firstCheckTestCase struct {
+	name     string
+	number   int
+	sha      string
+	action   string
+	expected bool
+}
+
+func TestFirstCheck(t *testing.T) {
+	// Cases run in order against the same bot
+	cases := []firstCheckTestCase{
+		{name: "FirstCheck", number: 1, sha: "abc", action: "opened", expected: true},
+		{name: "SameEventAgain", number: 1, sha: "abc", action: "opened", expected: false},
+		{name: "OtherAction", number: 1, sha: "abc", action: "reopened", expected: true},
+		{name: "OtherPullRequestWithSameHead", number: 2, sha: "abc", action: "opened", expected: true},
+		{name: "NewCommit", number: 1, sha: "def", action: "synchronize", expected: true},
+	}
+
+	b := &Bot{checked: lib.NewTTLSet(time.Hour)}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, b.firstCheck(testRepo, tc.number, tc.sha, tc.action))
+		})
+	}
+}
+
+type handleEventTestCase struct {
+	name           string
+	event          interface{}
+	expectedRoutes []string
+}
+
+func TestHandleEvent(t *testing.T) {
+	installation := &github.Installation{ID: github.Int64(1)}
+	sender := &github.User{Login: github.String("octocat"), Type: github.String("User")}
+
+	cases := []handleEventTestCase{
+		{
+			name: "DispatchesToHandler",
+			event: &github.IssuesEvent{
+				Action:       github.String("opened"),
+				Issue:        &github.Issue{Number: github.Int(1), Title: github.String("Rename master")},
+				Repo:         testRepo,
+				Sender:       sender,
+				Installation: installation,
+			},
+			expectedRoutes: []string{
+				"GET /api/v3/repos/zendesk/term-check/contents/.github/term-check.yaml",
+				"GET /api/v3/repos/zendesk/term-check/issues/1/comments",
+				"POST /api/v3/repos/zendesk/term-check/issues/1/comments",
+			},
+		},
+		{
+			name: "IrrelevantAction",
+			event: &github.RepositoryEvent{
+				Action:       github.String("deleted"),
+				Repo:         testRepo,
+				Sender:       sender,
+				Installation: installation,
+			},
+			expectedRoutes: nil,
+		},
+		{
+			name:           "UnhandledEvent",
+			event:          &github.PingEvent{},
+			expectedRoutes: nil,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := githubtest.NewServer(t)
+			s.Respond("GET /api/v3/repos/zendesk/term-check/issues/1/comments", http.StatusOK, `[]`)
+			s.Respond("POST /api/v3/repos/zendesk/term-check/issues/1/comments", http.StatusCreated, `{}`)
+
+			client, err := gh.NewClient(gh.WithToken("token"), gh.WithBaseURL(s.Client.BaseURL.String()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := newIssueBot()
+			b.client, b.withToken, b.tokenLogin = client, true, "term-check-user"
+
+			b.HandleEvent(context.Background(), tc.event)
+
+			assert.Equal(t, tc.expectedRoutes, s.Routes())
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/rs/zerolog/log"
+	"github.com/zendesk/term-check/pkg/lib"
+)
+
+const commandPrefix = "/term-check"
+
+// Commands that can be given in pull request comments
+const (
+	recheckCommand = "recheck"
+	ignoreCommand  = "ignore"
+	explainCommand = "explain"
+	helpCommand    = "help"
+)
+
+const commandHelp = "Available commands:\n\n" +
+	"* `/term-check recheck` - run the check again\n" +
+	"* `/term-check ignore <term>` - stop flagging a term in this pull request\n" +
+	"* `/term-check explain <term>` - explain why a term is flagged and what to use instead\n" +
+	"* `/term-check help` - show this message\n"
+
+// permissionsAllowedToCommand holds the repository permission levels allowed to run commands
+var permissionsAllowedToCommand = map[string]struct{}{
+	"admin": {},
+	"write": {},
+}
+
+// commandReplyMarker is hidden in the bot's replies to commands
+const commandReplyMarker = "<!-- term-check:reply -->"
+
+// command is a single slash command parsed from a comment
+type command struct {
+	name string
+	arg  string
+}
+
+// parseCommands returns the commands found in a comment body, one per line starting with `/term-check`
+func parseCommands(body string) []command {
+	var cmds []command
+
+	for _, line := range strings.Split(body, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != commandPrefix {
+			continue
+		}
+
+		c := command{name: helpCommand}
+		if len(fields) > 1 {
+			c.name = strings.ToLower(fields[1])
+		}
+		if len(fields) > 2 {
+			c.arg = strings.Join(fields[2:], " ")
+		}
+		cmds = append(cmds, c)
+	}
+
+	return cmds
+}
+
+// acceptsCommands returns whether commands in a comment by the passed in user are run. Other apps can echo user
+// controlled text, so only people can issue commands. In token mode the bot comments as a user people may share, so
+// only the bot's own comments, which hold its markers, are left out.
+func (b *Bot) acceptsCommands(ctx context.Context, u *github.User, body string) bool {
+	if u.GetType() == "Bot" {
+		return false
+	}
+	return !b.isBot(ctx, u) || !strings.Contains(body, markerPrefix)
+}
+
+// handleCommands runs the commands from a pull request comment, reacting to the comment or replying to confirm each
+func (b *Bot) handleCommands(ctx context.Context, cmds []command, event *github.IssueCommentEvent, ghc *github.Client) {
+	r := event.GetRepo()
+	owner, name := r.GetOwner().GetLogin(), r.GetName()
+	number := event.GetIssue().GetNumber()
+	comment := event.GetComment()
+	sender := event.GetSender().GetLogin()
+
+	perm, _, err := ghc.Repositories.GetPermissionLevel(ctx, owner, name, sender)
+	if err != nil {
+		log.Ctx(ctx).Error().Int("PR", number).Err(err).Msgf("Failed to get permission level of %s", sender)
+		return
+	}
+	if !lib.Contains(permissionsAllowedToCommand, perm.GetPermission()) {
+		log.Ctx(ctx).Info().Int("PR", number).Msgf("Ignoring commands from %s without write permission", sender)
+		b.react(ctx, r, ghc, comment, "confused")
+		b.reply(ctx, r, ghc, number, fmt.Sprintf("@%s only collaborators with write access can run %s commands.", sender, commandPrefix))
+		return
+	}
+
+	pr, resp, err := ghc.PullRequests.Get(ctx, owner, name, number)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		log.Ctx(ctx).Error().Int("PR", number).Err(err).Msg("Failed to get pull request")
+		return
+	}
+
+	recheck := false
+	for _, c := range cmds {
+		switch c.name {
+		case recheckCommand:
+			recheck = true
+			b.react(ctx, r, ghc, comment, "+1")
+		case ignoreCommand:
+			t, ok := b.lookupTerm(c.arg)
+			if !ok {
+				b.react(ctx, r, ghc, comment, "confused")
+				b.reply(ctx, r, ghc, number, "Usage: `/term-check ignore <term>`, where the term is one flagged by this check.")
+				continue
+			}
+			if err := b.ignoreTerm(ctx, pr, r, ghc, sender, t.source); err != nil {
+				log.Ctx(ctx).Error().Int("PR", number).Err(err).Msg("Failed to ignore term")
+				continue
+			}
+			recheck = true
+			b.react(ctx, r, ghc, comment, "+1")
+		case explainCommand:
+			b.reply(ctx, r, ghc, number, b.explain(c.arg))
+		case helpCommand:
+			b.reply(ctx, r, ghc, number, commandHelp)
+		default:
+			b.react(ctx, r, ghc, comment, "confused")
+			b.reply(ctx, r, ghc, number, "Unknown command.\n\n"+commandHelp)
+		}
+	}
+
+	if recheck {
+		b.createCheckRun(ctx, pr, r, ghc)
+	}
+}
+
+// explain returns the reply to an explain command for the passed in term
+func (b *Bot) explain(s string) string {
+	t, ok := b.lookupTerm(s)
+	if !ok {
+		return "That term is not flagged by this check. Use `/term-check help` for usage."
+	}
+
+	msg := b.annotationMessage([]string{t.source})
+	if len(t.alternatives) > 0 {
+		msg = fmt.Sprintf("%s\n\nSuggested alternatives: %s", msg, strings.Join(t.alternatives, ", "))
+	}
+	return msg
+}
+
+func (b *Bot) react(ctx context.Context, r *github.Repository, ghc *github.Client, c *github.IssueComment, reaction string) {
+	_, _, err := ghc.Reactions.CreateIssueCommentReaction(ctx, r.GetOwner().GetLogin(), r.GetName(), c.GetID(), reaction)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msgf("Failed to react to comment %d", c.GetID())
+	}
+}
+
+func (b *Bot) reply(ctx context.Context, r *github.Repository, ghc *github.Client, number int, body string) {
+	_, _, err := ghc.Issues.CreateComment(ctx, r.GetOwner().GetLogin(), r.GetName(), number, &github.IssueComment{
+		Body: github.String(body + "\n" + commandReplyMarker),
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Int("PR", number).Err(err).Msg("Failed to reply to comment")
+	}
+}
@@ -0,0 +1,102 @@
+package bot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/stretchr/testify/assert"
+)
+
+type parseCommandsTestCase struct {
+	name     string
+	body     string
+	expected []command
+}
+
+func TestParseCommands(t *testing.T) {
+	cases := []parseCommandsTestCase{
+		{
+			name:     "NoCommands",
+			body:     "LGTM, but see /term-check help",
+			expected: nil,
+		},
+		{
+			name:     "SingleCommand",
+			body:     "/term-check recheck",
+			expected: []command{{name: "recheck"}},
+		},
+		{
+			name:     "CommandWithArg",
+			body:     "Thanks!\r\n  /term-check Ignore master  \n",
+			expected: []command{{name: "ignore", arg: "master"}},
+		},
+		{
+			name:     "CommandWithMultiWordArg",
+			body:     "/term-check ignore master  branch",
+			expected: []command{{name: "ignore", arg: "master branch"}},
+		},
+		{
+			name:     "MultipleCommands",
+			body:     "/term-check explain slave\n/term-check\n",
+			expected: []command{{name: "explain", arg: "slave"}, {name: "help"}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, parseCommands(tc.body))
+		})
+	}
+}
+
+type acceptsCommandsTestCase struct {
+	name     string
+	bot      *Bot
+	user     *github.User
+	body     string
+	expected bool
+}
+
+func TestAcceptsCommands(t *testing.T) {
+	app := &Bot{appSlug: "term-check"}
+	token := &Bot{withToken: true, tokenLogin: "term-check-user"}
+	tokenUser := &github.User{Type: github.String("User"), Login: github.String("term-check-user")}
+
+	cases := []acceptsCommandsTestCase{
+		{
+			name:     "Person",
+			bot:      app,
+			user:     &github.User{Type: github.String("User"), Login: github.String("alice")},
+			body:     "/term-check recheck",
+			expected: true,
+		},
+		{
+			name:     "OtherApp",
+			bot:      app,
+			user:     &github.User{Type: github.String("Bot"), Login: github.String("echo[bot]")},
+			body:     "/term-check recheck",
+			expected: false,
+		},
+		{
+			name:     "TokenUserRunningCommands",
+			bot:      token,
+			user:     tokenUser,
+			body:     "/term-check recheck",
+			expected: true,
+		},
+		{
+			name:     "TokenUserReplying",
+			bot:      token,
+			user:     tokenUser,
+			body:     "Unknown command.\n\n/term-check help\n" + commandReplyMarker,
+			expected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.bot.acceptsCommands(context.Background(), tc.user, tc.body))
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/rs/zerolog/log"
+)
+
+// markerPrefix starts every marker the bot hides in its comments, issues and check runs
+const markerPrefix = "<!-- term-check:"
+
+// identity returns the login of the token's user in token mode, or else the slug of the app, which its bot user is
+// named after. It is looked up on first use rather than on startup, so the bot starts while GitHub is unavailable, and
+// looked up again on the next use after a failure.
+func (b *Bot) identity(ctx context.Context) (string, error) {
+	b.identityMu.Lock()
+	defer b.identityMu.Unlock()
+
+	if b.tokenMode() {
+		if b.tokenLogin == "" {
+			ghc, err := b.client.CreateClient(0)
+			if err != nil {
+				return "", err
+			}
+			user, _, err := ghc.Users.Get(ctx, "")
+			if err != nil {
+				return "", fmt.Errorf("Failed to get the user of the token: %s", err)
+			}
+			b.tokenLogin = user.GetLogin()
+			log.Ctx(ctx).Info().Msgf("Authenticating as %s with a personal access token", b.tokenLogin)
+		}
+		return b.tokenLogin, nil
+	}
+
+	if b.appSlug == "" {
+		ghc, err := b.client.CreateAppClient()
+		if err != nil {
+			return "", err
+		}
+		app, _, err := ghc.Apps.Get(ctx, "")
+		if err != nil {
+			return "", fmt.Errorf("Failed to get the app: %s", err)
+		}
+		b.appSlug = app.GetSlug()
+	}
+	return b.appSlug, nil
+}
+
+// isBot returns whether a user is the bot. Applications comment as their own bot user, named after the app's slug,
+// while in token mode the bot comments as the token's user. Other apps' bot users are not the bot, as they can echo
+// user controlled text, e.x. the bot's markers. Users are not taken for the bot while its identity is unknown.
+func (b *Bot) isBot(ctx context.Context, u *github.User) bool {
+	id, err := b.identity(ctx)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to look up the bot's identity")
+		return false
+	}
+
+	if b.tokenMode() {
+		return strings.EqualFold(u.GetLogin(), id)
+	}
+	// The REST API suffixes the logins of bot users with [bot], the GraphQL API does not
+	return u.GetType() == "Bot" && strings.EqualFold(strings.TrimSuffix(u.GetLogin(), "[bot]"), id)
+}
+
+// login returns the login of the user the bot acts as through the REST API
+func (b *Bot) login(ctx context.Context) (string, error) {
+	id, err := b.identity(ctx)
+	if err != nil || b.tokenMode() {
+		return id, err
+	}
+	return id + "[bot]", nil
+}
+
+// findBotIssue returns the bot's open issue holding the passed in marker in its body, or nil if there isn't one
+func (b *Bot) findBotIssue(ctx context.Context, r *github.Repository, marker string, ghc *github.Client) (*github.Issue, error) {
+	login, err := b.login(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &github.IssueListByRepoOptions{
+		Creator:     login,
+		State:       "open",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		issues, resp, err := ghc.Issues.ListByRepo(ctx, r.GetOwner().GetLogin(), r.GetName(), opts)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list issues of %s: %s", r.GetFullName(), err)
+		}
+
+		for _, i := range issues {
+			if !i.IsPullRequest() && b.isBot(ctx, i.GetUser()) && strings.Contains(i.GetBody(), marker) {
+				return i, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// findBotComment returns the bot's comment holding the passed in marker on an issue or pull request, or nil if there
+// isn't one
+func (b *Bot) findBotComment(ctx context.Context, r *github.Repository, number int, marker string, ghc *github.Client) (*github.IssueComment, error) {
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		comments, resp, err := ghc.Issues.ListComments(ctx, r.GetOwner().GetLogin(), r.GetName(), number, opts)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list comments on #%d: %s", number, err)
+		}
+
+		for _, c := range comments {
+			if b.isBot(ctx, c.GetUser()) && strings.Contains(c.GetBody(), marker) {
+				return c, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// writeBotComment edits the bot's existing comment on an issue or pull request, creating it if existing is nil
+func writeBotComment(ctx context.Context, r *github.Repository, number int, existing *github.IssueComment, body string, ghc *github.Client) error {
+	owner, name := r.GetOwner().GetLogin(), r.GetName()
+	comment := &github.IssueComment{Body: github.String(body)}
+
+	if existing == nil {
+		_, resp, err := ghc.Issues.CreateComment(ctx, owner, name, number, comment)
+		if err != nil || resp.StatusCode != http.StatusCreated {
+			return fmt.Errorf("Failed to create comment on #%d: %s", number, err)
+		}
+		return nil
+	}
+
+	_, resp, err := ghc.Issues.EditComment(ctx, owner, name, existing.GetID(), comment)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Failed to edit comment on #%d: %s", number, err)
+	}
+	return nil
+}
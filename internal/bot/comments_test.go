@@ -0,0 +1,90 @@
+package bot
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/zendesk/term-check/internal/githubtest"
+	gh "github.com/zendesk/term-check/pkg/github"
+)
+
+type isBotTestCase struct {
+	name     string
+	bot      *Bot
+	user     *github.User
+	expected bool
+}
+
+func TestIsBot(t *testing.T) {
+	app := &Bot{appSlug: "term-check"}
+	token := &Bot{withToken: true, tokenLogin: "term-check-user"}
+
+	cases := []isBotTestCase{
+		{
+			name:     "AppBotUser",
+			bot:      app,
+			user:     &github.User{Type: github.String("Bot"), Login: github.String("term-check[bot]")},
+			expected: true,
+		},
+		{
+			name:     "AppBotUserFromGraphQL",
+			bot:      app,
+			user:     &github.User{Type: github.String("Bot"), Login: github.String("term-check")},
+			expected: true,
+		},
+		{
+			name:     "OtherBotUser",
+			bot:      app,
+			user:     &github.User{Type: github.String("Bot"), Login: github.String("dependabot[bot]")},
+			expected: false,
+		},
+		{
+			name:     "UserNamedAfterApp",
+			bot:      app,
+			user:     &github.User{Type: github.String("User"), Login: github.String("term-check")},
+			expected: false,
+		},
+		{
+			name:     "TokenUser",
+			bot:      token,
+			user:     &github.User{Type: github.String("User"), Login: github.String("Term-Check-User")},
+			expected: true,
+		},
+		{
+			name:     "BotUserInTokenMode",
+			bot:      token,
+			user:     &github.User{Type: github.String("Bot"), Login: github.String("term-check[bot]")},
+			expected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.bot.isBot(context.Background(), tc.user))
+		})
+	}
+}
+
+func TestIdentityIsResolvedOnFirstUseAndRetried(t *testing.T) {
+	s := githubtest.NewServer(t)
+	s.Respond("GET /api/v3/user", http.StatusUnauthorized, `{"message": "Bad credentials"}`)
+
+	client, err := gh.NewClient(gh.WithToken("token"), gh.WithBaseURL(s.Client.BaseURL.String()))
+	assert.NoError(t, err)
+	b := &Bot{client: client, withToken: true}
+	assert.Empty(t, s.Routes())
+
+	user := &github.User{Type: github.String("User"), Login: github.String("term-check-user")}
+	assert.False(t, b.isBot(context.Background(), user))
+
+	s.Respond("GET /api/v3/user", http.StatusOK, `{"login": "term-check-user"}`)
+	assert.True(t, b.isBot(context.Background(), user))
+	login, err := b.login(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "term-check-user", login)
+
+	assert.Equal(t, []string{"GET /api/v3/user", "GET /api/v3/user"}, s.Routes())
+}
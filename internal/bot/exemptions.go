@@ -0,0 +1,128 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+)
+
+// ignoreMarker is hidden in the comment the bot leaves when a pull request is ignored, holding who ignored it. The
+// comment doubles as the record of the exemption, so later runs keep honoring it.
+const ignoreMarker = "<!-- term-check:ignore-pr by=%s -->"
+
+// ignoreTermMarker is hidden in the comment the bot leaves when a single term is ignored for a pull request, holding
+// who ignored it and the term. The term is escaped, as configured terms may hold whitespace or end the comment.
+const ignoreTermMarker = "<!-- term-check:ignore-term by=%s term=%s -->"
+
+var (
+	ignoreMarkerPattern     = regexp.MustCompile(`<!-- term-check:ignore-pr by=(\S+) -->`)
+	ignoreTermMarkerPattern = regexp.MustCompile(`<!-- term-check:ignore-term by=(\S+) term=(\S+) -->`)
+)
+
+// exemptions holds what has been exempted from the term check for a single pull request
+type exemptions struct {
+	pr    bool
+	prBy  string
+	terms map[string]string // ignored term -> who ignored it
+}
+
+// filter returns the passed in findings without any of the terms that have been ignored
+func (b *Bot) filter(findings []finding, e *exemptions) []finding {
+	if len(e.terms) == 0 {
+		return findings
+	}
+
+	var filtered []finding
+	for _, f := range findings {
+		var terms []string
+		for _, m := range f.terms {
+			if !b.termIgnored(m, e) {
+				terms = append(terms, m)
+			}
+		}
+		if len(terms) > 0 {
+			f.terms = terms
+			filtered = append(filtered, f)
+		}
+	}
+	return filtered
+}
+
+// termIgnored returns whether a matched term has been ignored, either by itself or through the configured term
+// matching it
+func (b *Bot) termIgnored(match string, e *exemptions) bool {
+	for ignored := range e.terms {
+		if strings.EqualFold(match, ignored) {
+			return true
+		}
+		for _, t := range b.terms {
+			if t.source == ignored && t.pattern.MatchString(match) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getExemptions reads the exemptions recorded in the bot's own comments on a pull request
+func (b *Bot) getExemptions(ctx context.Context, pr *github.PullRequest, r *github.Repository, ghc *github.Client) (*exemptions, error) {
+	e := exemptions{terms: make(map[string]string)}
+
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		comments, resp, err := ghc.Issues.ListComments(ctx, r.GetOwner().GetLogin(), r.GetName(), pr.GetNumber(), opts)
+		if err != nil {
+			return &e, fmt.Errorf("Failed to list comments for %s: %s", pr.GetHead().GetSHA(), err)
+		}
+
+		for _, c := range comments {
+			if !b.isBot(ctx, c.GetUser()) {
+				continue
+			}
+			if m := ignoreMarkerPattern.FindStringSubmatch(c.GetBody()); m != nil {
+				e.pr = true
+				e.prBy = m[1]
+			}
+			for _, m := range ignoreTermMarkerPattern.FindAllStringSubmatch(c.GetBody(), -1) {
+				t, err := url.PathUnescape(m[2])
+				if err != nil {
+					continue
+				}
+				e.terms[t] = m[1]
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return &e, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ignorePullRequest records that the term check is ignored for a pull request by leaving a comment on it
+func (b *Bot) ignorePullRequest(ctx context.Context, pr *github.PullRequest, r *github.Repository, ghc *github.Client, sender string) error {
+	body := fmt.Sprintf("%s ignored for this pull request by @%s\n"+ignoreMarker, b.checkName, sender, sender)
+	return b.recordExemption(ctx, pr, r, ghc, body)
+}
+
+// ignoreTerm records that a term is ignored for a pull request by leaving a comment on it
+func (b *Bot) ignoreTerm(ctx context.Context, pr *github.PullRequest, r *github.Repository, ghc *github.Client, sender string, t string) error {
+	body := fmt.Sprintf("`%s` ignored for this pull request by @%s\n"+ignoreTermMarker, t, sender, sender, url.PathEscape(t))
+	return b.recordExemption(ctx, pr, r, ghc, body)
+}
+
+func (b *Bot) recordExemption(ctx context.Context, pr *github.PullRequest, r *github.Repository, ghc *github.Client, body string) error {
+	_, resp, err := ghc.Issues.CreateComment(ctx, r.GetOwner().GetLogin(), r.GetName(), pr.GetNumber(), &github.IssueComment{
+		Body: github.String(body),
+	})
+	if err != nil || resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("Failed to record exemption for %s: %s", pr.GetHead().GetSHA(), err)
+	}
+
+	return nil
+}
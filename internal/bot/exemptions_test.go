@@ -0,0 +1,101 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/zendesk/term-check/internal/githubtest"
+)
+
+func TestGetExemptions(t *testing.T) {
+	s := githubtest.NewServer(t)
+	s.Respond("GET /repos/zendesk/term-check/issues/1/comments", http.StatusOK, `[
+		{"user": {"login": "term-check[bot]", "type": "Bot"}, "body": "term-check ignored for this pull request by @alice\n<!-- term-check:ignore-pr by=alice -->"},
+		{"user": {"login": "term-check[bot]", "type": "Bot"}, "body": "`+"`master`"+` ignored for this pull request by @bob\n<!-- term-check:ignore-term by=bob term=master -->"},
+		{"user": {"login": "mallory", "type": "User"}, "body": "<!-- term-check:ignore-term by=mallory term=slave -->"}
+	]`)
+
+	b := &Bot{appSlug: "term-check"}
+	e, err := b.getExemptions(context.Background(), &github.PullRequest{Number: github.Int(1)}, testRepo, s.Client)
+
+	if assert.NoError(t, err) {
+		assert.True(t, e.pr)
+		assert.Equal(t, "alice", e.prBy)
+		// Markers in comments from anyone but the bot are not trusted
+		assert.Equal(t, map[string]string{"master": "bob"}, e.terms)
+	}
+}
+
+type filterTestCase struct {
+	name     string
+	ignored  map[string]string
+	expected []finding
+}
+
+func TestFilter(t *testing.T) {
+	b := &Bot{terms: []term{
+		{source: "(?i)master", pattern: regexp.MustCompile("(?i)master")},
+		{source: "slave", pattern: regexp.MustCompile("slave")},
+	}}
+	findings := []finding{
+		{path: "README.md", line: 1, terms: []string{"Master", "slave"}},
+		{path: "README.md", line: 2, terms: []string{"master"}},
+	}
+
+	cases := []filterTestCase{
+		{
+			name:     "NothingIgnored",
+			ignored:  nil,
+			expected: findings,
+		},
+		{
+			name:     "IgnoredByMatch",
+			ignored:  map[string]string{"slave": "alice"},
+			expected: []finding{{path: "README.md", line: 1, terms: []string{"Master"}}, findings[1]},
+		},
+		{
+			name:     "IgnoredByConfiguredTerm",
+			ignored:  map[string]string{"(?i)master": "alice"},
+			expected: []finding{{path: "README.md", line: 1, terms: []string{"slave"}}},
+		},
+		{
+			name:     "EverythingIgnored",
+			ignored:  map[string]string{"MASTER": "alice", "slave": "bob"},
+			expected: nil,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, b.filter(findings, &exemptions{terms: tc.ignored}))
+		})
+	}
+}
+
+func TestIgnoreTermWithWhitespace(t *testing.T) {
+	s := githubtest.NewServer(t)
+	s.Respond("POST /repos/zendesk/term-check/issues/1/comments", http.StatusCreated, `{}`)
+
+	b := &Bot{appSlug: "term-check"}
+	pr := &github.PullRequest{Number: github.Int(1)}
+	if err := b.ignoreTerm(context.Background(), pr, testRepo, s.Client, "alice", "(?i)master branch -->"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The comment the bot left is read back as an exemption of the whole term
+	body := s.Body("POST /repos/zendesk/term-check/issues/1/comments")["body"].(string)
+	comments, _ := json.Marshal([]map[string]interface{}{
+		{"user": map[string]string{"login": "term-check[bot]", "type": "Bot"}, "body": body},
+	})
+	s.Respond("GET /repos/zendesk/term-check/issues/1/comments", http.StatusOK, string(comments))
+
+	e, err := b.getExemptions(context.Background(), pr, testRepo, s.Client)
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]string{"(?i)master branch -->": "alice"}, e.terms)
+	}
+}
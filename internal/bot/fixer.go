@@ -0,0 +1,208 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/rs/zerolog/log"
+	"github.com/zendesk/term-check/internal/config"
+	"github.com/zendesk/term-check/pkg/lib"
+)
+
+const defaultFileMode = "100644"
+
+// errForkNotWritable is returned when the head branch of a pull request lives in a fork. Installation tokens are scoped
+// to the repositories the app is installed on, so they cannot push to forks even when maintainers are allowed to.
+var errForkNotWritable = errors.New("the head branch is in a fork, which the app cannot push to")
+
+// fix is a single line rewritten by the fixer
+type fix struct {
+	path   string
+	line   int
+	before string
+	after  string
+}
+
+// applyFixes rewrites every line with findings in a pull request, replacing each flagged term by its first
+// alternative in the casing of the original. All changes go into a single commit on the head branch, made through the
+// Git Data API. Returns the fixes made along with the SHA of the new commit.
+func (b *Bot) applyFixes(ctx context.Context, pr *github.PullRequest, r *github.Repository, ghc *github.Client) ([]fix, string, error) {
+	owner, name := r.GetOwner().GetLogin(), r.GetName()
+
+	// Pull requests attached to events only hold a summary, so get the full one to see where the head branch lives
+	full, resp, err := ghc.PullRequests.Get(ctx, owner, name, pr.GetNumber())
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("Failed to get pull request #%d: %s", pr.GetNumber(), err)
+	}
+	pr = full
+	headSHA := pr.GetHead().GetSHA()
+
+	if pr.GetHead().GetRepo().GetID() != r.GetID() {
+		return nil, "", errForkNotWritable
+	}
+
+	rc, err := config.GetRepoConfig(ctx, r, headSHA, ghc)
+	if err != nil {
+		return nil, "", err
+	}
+	sc, err := b.scanPullRequest(ctx, pr, r, ghc, rc)
+	if err != nil {
+		return nil, "", err
+	}
+	ex, err := b.getExemptions(ctx, pr, r, ghc)
+	if err != nil {
+		return nil, "", err
+	}
+	findings := b.filter(sc.findings, ex)
+
+	commit, resp, err := ghc.Git.GetCommit(ctx, owner, name, headSHA)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("Failed to get commit %s: %s", headSHA, err)
+	}
+	modes := fileModes(ctx, owner, name, commit.GetTree().GetSHA(), ghc)
+
+	var fixes []fix
+	var entries []*github.TreeEntry
+	for _, p := range findingPaths(findings) {
+		fc, _, resp, err := ghc.Repositories.GetContents(ctx, owner, name, p, &github.RepositoryContentGetOptions{Ref: headSHA})
+		if err != nil || resp.StatusCode != http.StatusOK {
+			return nil, "", fmt.Errorf("Failed to get %s for %s: %s", p, headSHA, err)
+		}
+		content, err := fc.GetContent()
+		if err != nil {
+			return nil, "", fmt.Errorf("Failed to decode %s for %s: %s", p, headSHA, err)
+		}
+
+		lines := strings.Split(content, "\n")
+		var fileFixes []fix
+		for _, f := range findings {
+			if f.path != p || f.line > len(lines) || lines[f.line-1] != f.content {
+				continue
+			}
+			if s, ok := b.suggest(f); ok {
+				lines[f.line-1] = s
+				fileFixes = append(fileFixes, fix{path: p, line: f.line, before: f.content, after: s})
+			}
+		}
+		if len(fileFixes) == 0 {
+			continue
+		}
+
+		mode, ok := modes[p]
+		if !ok {
+			mode = defaultFileMode
+		}
+		entries = append(entries, &github.TreeEntry{
+			Path:    github.String(p),
+			Mode:    github.String(mode),
+			Type:    github.String("blob"),
+			Content: github.String(strings.Join(lines, "\n")),
+		})
+		fixes = append(fixes, fileFixes...)
+	}
+
+	if len(fixes) == 0 {
+		return nil, "", nil
+	}
+
+	tree, resp, err := ghc.Git.CreateTree(ctx, owner, name, commit.GetTree().GetSHA(), entries)
+	if err != nil || resp.StatusCode != http.StatusCreated {
+		return nil, "", fmt.Errorf("Failed to create tree for %s: %s", headSHA, err)
+	}
+
+	newCommit, resp, err := ghc.Git.CreateCommit(ctx, owner, name, &github.Commit{
+		Message: github.String(fmt.Sprintf("Replace terms flagged by %s", b.checkName)),
+		Tree:    tree,
+		Parents: []*github.Commit{{SHA: github.String(headSHA)}},
+	})
+	if err != nil || resp.StatusCode != http.StatusCreated {
+		return nil, "", fmt.Errorf("Failed to create commit for %s: %s", headSHA, err)
+	}
+
+	// Not forced, so the update fails rather than dropping commits if the branch moved on since the scan
+	ref := &github.Reference{
+		Ref:    github.String("heads/" + pr.GetHead().GetRef()),
+		Object: &github.GitObject{SHA: newCommit.SHA},
+	}
+	if _, resp, err := ghc.Git.UpdateRef(ctx, owner, name, ref, false); err != nil || resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("Failed to update %s for %s: %s", pr.GetHead().GetRef(), headSHA, err)
+	}
+
+	return fixes, newCommit.GetSHA(), nil
+}
+
+// reportFixes adds the outcome of applying fixes to a check run's output, keeping the findings it already shows.
+// Annotations are left out of the update, so the existing ones stay as they are.
+func (b *Bot) reportFixes(ctx context.Context, cr *github.CheckRun, r *github.Repository, ghc *github.Client, fixes []fix, commitSHA string, fixErr error) {
+	var summary, text strings.Builder
+	switch {
+	case fixErr != nil:
+		fmt.Fprintf(&summary, "Could not apply suggested fixes: %s", fixErr)
+	case len(fixes) == 0:
+		fmt.Fprint(&summary, "No suggested fixes to apply")
+	default:
+		fmt.Fprintf(&summary, "Applied %d suggested fix(es) in %s", len(fixes), commitSHA)
+		for _, f := range fixes {
+			fmt.Fprintf(&text, "* `%s` line %d: `%s` → `%s`\n", f.path, f.line, strings.TrimSpace(f.before), strings.TrimSpace(f.after))
+		}
+	}
+
+	output := cr.GetOutput()
+	title := output.GetTitle()
+	if title == "" {
+		title = b.checkName
+	}
+
+	_, resp, err := ghc.Checks.UpdateCheckRun(ctx, r.GetOwner().GetLogin(), r.GetName(), cr.GetID(), github.UpdateCheckRunOptions{
+		Name: b.checkName,
+		Output: &github.CheckRunOutput{
+			Title:   github.String(title),
+			Summary: github.String(appendSection(output.GetSummary(), summary.String())),
+			Text:    github.String(appendSection(output.GetText(), text.String())),
+		},
+	})
+	if err != nil || resp.StatusCode != http.StatusOK {
+		log.Ctx(ctx).Error().Str("SHA", cr.GetHeadSHA()).Err(err).Msg("Failed to report fixes on CheckRun")
+	}
+}
+
+// appendSection returns text with section added after a blank line, or section alone when text is empty
+func appendSection(text, section string) string {
+	text, section = strings.TrimSpace(text), strings.TrimSpace(section)
+	if text == "" || section == "" {
+		return text + section
+	}
+	return text + "\n\n" + section
+}
+
+// fileModes returns the mode of every file in a tree. Modes are best effort, as an error or a truncated tree only means
+// missing files fall back to the default mode.
+func fileModes(ctx context.Context, owner, name, treeSHA string, ghc *github.Client) map[string]string {
+	modes := make(map[string]string)
+
+	tree, _, err := ghc.Git.GetTree(ctx, owner, name, treeSHA, true)
+	if err != nil {
+		return modes
+	}
+	for _, e := range tree.Entries {
+		modes[e.GetPath()] = e.GetMode()
+	}
+	return modes
+}
+
+// findingPaths returns the distinct paths of the passed in findings in the order they first appear
+func findingPaths(findings []finding) []string {
+	var paths []string
+	seen := make(map[string]struct{})
+	for _, f := range findings {
+		if !lib.Contains(seen, f.path) {
+			seen[f.path] = struct{}{}
+			paths = append(paths, f.path)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,159 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/zendesk/term-check/internal/githubtest"
+)
+
+type findingPathsTestCase struct {
+	name     string
+	findings []finding
+	expected []string
+}
+
+func TestFindingPaths(t *testing.T) {
+	cases := []findingPathsTestCase{
+		{
+			name:     "NoFindings",
+			findings: nil,
+			expected: nil,
+		},
+		{
+			name:     "DistinctInOrder",
+			findings: []finding{{path: "b.md", line: 1}, {path: "a.md", line: 2}, {path: "b.md", line: 3}, {path: "c.md", line: 1}},
+			expected: []string{"b.md", "a.md", "c.md"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, findingPaths(tc.findings))
+		})
+	}
+}
+
+const fixerDiff = `diff --git a/README.md b/README.md
+index 1111111..2222222 100755
+--- a/README.md
++++ b/README.md
+@@ -1,2 +1,2 @@
+ # Term Check
+-Use the main branch
++Use the Master branch
+`
+
+// newFakeFixerAPI starts a fake GitHub API serving the parts applying fixes uses, on a pull request whose head repo
+// has the passed in ID
+func newFakeFixerAPI(t *testing.T, headRepoID int) *githubtest.Server {
+	s := githubtest.NewServer(t)
+	s.Handle("GET /repos/zendesk/term-check/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.Header.Get("Accept"), "diff") {
+			fmt.Fprint(w, fixerDiff)
+			return
+		}
+		fmt.Fprintf(w, `{"number": 1, "maintainer_can_modify": true, "head": {"sha": "abc", "ref": "feature", "repo": {"id": %d, "name": "term-check", "owner": {"login": "zendesk"}}}}`, headRepoID)
+	})
+	s.Respond("GET /repos/zendesk/term-check/issues/1/comments", http.StatusOK, `[]`)
+	s.Respond("GET /repos/zendesk/term-check/git/commits/abc", http.StatusOK, `{"sha": "abc", "tree": {"sha": "tree1"}}`)
+	s.Respond("GET /repos/zendesk/term-check/git/trees/tree1", http.StatusOK, `{"sha": "tree1", "tree": [{"path": "README.md", "mode": "100755", "type": "blob"}]}`)
+	s.Respond("GET /repos/zendesk/term-check/contents/README.md", http.StatusOK, githubtest.Contents("# Term Check\nUse the Master branch\n"))
+	s.Respond("POST /repos/zendesk/term-check/git/trees", http.StatusCreated, `{"sha": "tree2"}`)
+	s.Respond("POST /repos/zendesk/term-check/git/commits", http.StatusCreated, `{"sha": "def"}`)
+	s.Respond("PATCH /repos/zendesk/term-check/git/refs/heads/feature", http.StatusOK, `{"ref": "refs/heads/feature", "object": {"sha": "def"}}`)
+	return s
+}
+
+func TestApplyFixes(t *testing.T) {
+	s := newFakeFixerAPI(t, 1)
+
+	b := &Bot{
+		checkName:   "term-check",
+		termPattern: regexp.MustCompile("(?i)master"),
+		terms:       []term{{source: "(?i)master", pattern: regexp.MustCompile("(?i)master"), alternatives: []string{"main"}}},
+	}
+	r := &github.Repository{ID: github.Int64(1), Name: github.String("term-check"), Owner: &github.User{Login: github.String("zendesk")}}
+
+	fixes, sha, err := b.applyFixes(context.Background(), &github.PullRequest{Number: github.Int(1)}, r, s.Client)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "def", sha)
+	assert.Equal(t, []fix{{path: "README.md", line: 2, before: "Use the Master branch", after: "Use the Main branch"}}, fixes)
+	if tree := s.Body("POST /repos/zendesk/term-check/git/trees"); assert.NotNil(t, tree) {
+		entries := tree["tree"].([]interface{})
+		entry := entries[0].(map[string]interface{})
+		assert.Equal(t, "# Term Check\nUse the Main branch\n", entry["content"])
+		// The file keeps its mode
+		assert.Equal(t, "100755", entry["mode"])
+	}
+}
+
+func TestApplyFixesForkNotWritable(t *testing.T) {
+	s := newFakeFixerAPI(t, 2)
+
+	b := &Bot{checkName: "term-check"}
+	r := &github.Repository{ID: github.Int64(1), Name: github.String("term-check"), Owner: &github.User{Login: github.String("zendesk")}}
+
+	// Forks are refused even when maintainers may edit them, as the installation token has no access to them
+	_, _, err := b.applyFixes(context.Background(), &github.PullRequest{Number: github.Int(1)}, r, s.Client)
+	assert.Equal(t, errForkNotWritable, err)
+	assert.Nil(t, s.Body("POST /repos/zendesk/term-check/git/trees"))
+}
+
+type reportFixesTestCase struct {
+	name            string
+	fixes           []fix
+	fixErr          error
+	expectedSummary string
+	expectedText    string
+}
+
+func TestReportFixes(t *testing.T) {
+	cases := []reportFixesTestCase{
+		{
+			name:            "Applied",
+			fixes:           []fix{{path: "README.md", line: 2, before: "Use the Master branch", after: "Use the Main branch"}},
+			expectedSummary: "Flagged terms found\n\nApplied 1 suggested fix(es) in def",
+			expectedText:    "| README.md | 2 | `Master` |\n\n* `README.md` line 2: `Use the Master branch` → `Use the Main branch`",
+		},
+		{
+			name:            "Failed",
+			fixErr:          errForkNotWritable,
+			expectedSummary: "Flagged terms found\n\nCould not apply suggested fixes: " + errForkNotWritable.Error(),
+			expectedText:    "| README.md | 2 | `Master` |",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newFakeChecks(t, `{"total_count": 0, "check_runs": []}`, "[]")
+
+			b := &Bot{checkName: "term-check"}
+			cr := &github.CheckRun{ID: github.Int64(1), HeadSHA: github.String("abc"), Output: &github.CheckRunOutput{
+				Title:   github.String("term-check"),
+				Summary: github.String("Flagged terms found"),
+				Text:    github.String("| README.md | 2 | `Master` |"),
+			}}
+			b.reportFixes(context.Background(), cr, testRepo, s.Client, tc.fixes, "def", tc.fixErr)
+
+			body := s.Body("PATCH /repos/zendesk/term-check/check-runs/1")
+			if assert.NotNil(t, body) {
+				// The findings stay on the check run along with the outcome of the fixes
+				output := body["output"].(map[string]interface{})
+				assert.Equal(t, "term-check", output["title"])
+				assert.Equal(t, tc.expectedSummary, output["summary"])
+				assert.Equal(t, tc.expectedText, output["text"])
+				assert.NotContains(t, output, "annotations")
+			}
+		})
+	}
+}
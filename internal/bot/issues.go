@@ -0,0 +1,186 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/rs/zerolog/log"
+	"github.com/zendesk/term-check/internal/config"
+	gh "github.com/zendesk/term-check/pkg/github"
+	"github.com/zendesk/term-check/pkg/lib"
+)
+
+// issueMarker is hidden in the bot's reply to an issue or discussion so later edits update it
+const issueMarker = "<!-- term-check:issue -->"
+
+const (
+	discussionCommentsQuery = `query($owner: String!, $name: String!, $number: Int!) {
+  repository(owner: $owner, name: $name) {
+    discussion(number: $number) {
+      comments(first: 100) { nodes { id body author { __typename login } } }
+    }
+  }
+}`
+	addDiscussionCommentMutation = `mutation($id: ID!, $body: String!) {
+  addDiscussionComment(input: {discussionId: $id, body: $body}) { comment { id } }
+}`
+	updateDiscussionCommentMutation = `mutation($id: ID!, $body: String!) {
+  updateDiscussionComment(input: {commentId: $id, body: $body}) { comment { id } }
+}`
+)
+
+// scanIssuesFor returns whether issues and discussions should be checked for a repo, preferring the repo's own
+// setting
+func (b *Bot) scanIssuesFor(rc *config.RepoConfig) bool {
+	if rc.ScanIssues != nil {
+		return *rc.ScanIssues
+	}
+	return b.scanIssues
+}
+
+// findInText returns the distinct flagged terms used in the passed in texts
+func (b *Bot) findInText(texts ...string) []string {
+	var matches []string
+	for _, t := range texts {
+		matches = append(matches, b.termPattern.FindAllString(t, -1)...)
+	}
+	return lib.Unique(matches)
+}
+
+// mayNeedReply returns whether an issue or discussion with the passed in title and body uses flagged terms, or used
+// them before the passed in edit. Only then can a reply be needed, to flag the terms or to thank for removing them.
+func (b *Bot) mayNeedReply(title, body string, changes *github.EditChange) bool {
+	texts := []string{title, body}
+	if changes != nil && changes.Title != nil && changes.Title.From != nil {
+		texts = append(texts, *changes.Title.From)
+	}
+	if changes != nil && changes.Body != nil && changes.Body.From != nil {
+		texts = append(texts, *changes.Body.From)
+	}
+	return len(b.findInText(texts...)) > 0
+}
+
+// issueReply renders the bot's reply to an issue or discussion using the passed in terms
+func (b *Bot) issueReply(terms []string) string {
+	var sb strings.Builder
+
+	if len(terms) == 0 {
+		fmt.Fprintf(&sb, "%s Thanks for updating, no flagged terms are used anymore.\n", b.checkSuccessSummary)
+	} else {
+		fmt.Fprintf(&sb, "%s\n\n", strings.TrimSpace(b.annotationMessage(terms)))
+		for _, m := range terms {
+			fmt.Fprintf(&sb, "* `%s`", m)
+			if t, ok := b.lookupTerm(m); ok && len(t.alternatives) > 0 {
+				fmt.Fprintf(&sb, " - consider %s", strings.Join(t.alternatives, ", "))
+			}
+			fmt.Fprint(&sb, "\n")
+		}
+	}
+	fmt.Fprintf(&sb, "\n%s\n", issueMarker)
+
+	return sb.String()
+}
+
+// checkIssue checks the title and body of an issue, replying with a single comment listing the flagged terms found.
+// The comment is updated on later edits, and only created once terms are found.
+func (b *Bot) checkIssue(ctx context.Context, issue *github.Issue, changes *github.EditChange, r *github.Repository, ghc *github.Client) {
+	number := issue.GetNumber()
+
+	// Spares fetching the configuration for the many issues that never used flagged terms
+	if !b.mayNeedReply(issue.GetTitle(), issue.GetBody(), changes) {
+		return
+	}
+
+	rc, err := config.GetRepoConfig(ctx, r, "", ghc)
+	if err != nil {
+		log.Ctx(ctx).Error().Int("Issue", number).Err(err).Msg("Failed to get repository configuration")
+		return
+	}
+	if !b.scanIssuesFor(rc) {
+		return
+	}
+
+	terms := b.findInText(issue.GetTitle(), issue.GetBody())
+
+	existing, err := b.findBotComment(ctx, r, number, issueMarker, ghc)
+	if err != nil {
+		log.Ctx(ctx).Error().Int("Issue", number).Err(err).Msg("Failed to find reply")
+		return
+	}
+	if existing == nil && len(terms) == 0 {
+		return
+	}
+
+	if err := writeBotComment(ctx, r, number, existing, b.issueReply(terms), ghc); err != nil {
+		log.Ctx(ctx).Error().Int("Issue", number).Err(err).Msg("Failed to reply")
+		return
+	}
+	log.Ctx(ctx).Info().Int("Issue", number).Msgf("Replied with %d flagged term(s)", len(terms))
+}
+
+// checkDiscussion checks the title and body of a discussion in the same way as checkIssue. Discussion comments are
+// only available through the GraphQL API.
+func (b *Bot) checkDiscussion(ctx context.Context, d *gh.Discussion, changes *github.EditChange, r *github.Repository, ghc *github.Client) {
+	number := d.GetNumber()
+
+	if !b.mayNeedReply(d.GetTitle(), d.GetBody(), changes) {
+		return
+	}
+
+	rc, err := config.GetRepoConfig(ctx, r, "", ghc)
+	if err != nil {
+		log.Ctx(ctx).Error().Int("Discussion", number).Err(err).Msg("Failed to get repository configuration")
+		return
+	}
+	if !b.scanIssuesFor(rc) {
+		return
+	}
+
+	terms := b.findInText(d.GetTitle(), d.GetBody())
+
+	var data struct {
+		Repository struct {
+			Discussion struct {
+				Comments struct {
+					Nodes []struct {
+						ID     string `json:"id"`
+						Body   string `json:"body"`
+						Author struct {
+							Typename string `json:"__typename"`
+							Login    string `json:"login"`
+						} `json:"author"`
+					} `json:"nodes"`
+				} `json:"comments"`
+			} `json:"discussion"`
+		} `json:"repository"`
+	}
+	vars := map[string]interface{}{"owner": r.GetOwner().GetLogin(), "name": r.GetName(), "number": number}
+	if err := gh.GraphQL(ctx, ghc, discussionCommentsQuery, vars, &data); err != nil {
+		log.Ctx(ctx).Error().Int("Discussion", number).Err(err).Msg("Failed to find reply")
+		return
+	}
+
+	existingID := ""
+	for _, c := range data.Repository.Discussion.Comments.Nodes {
+		if b.isBot(ctx, &github.User{Type: github.String(c.Author.Typename), Login: github.String(c.Author.Login)}) && strings.Contains(c.Body, issueMarker) {
+			existingID = c.ID
+			break
+		}
+	}
+	if existingID == "" && len(terms) == 0 {
+		return
+	}
+
+	mutation, id := addDiscussionCommentMutation, d.GetNodeID()
+	if existingID != "" {
+		mutation, id = updateDiscussionCommentMutation, existingID
+	}
+	var res interface{}
+	if err := gh.GraphQL(ctx, ghc, mutation, map[string]interface{}{"id": id, "body": b.issueReply(terms)}, &res); err != nil {
+		log.Ctx(ctx).Error().Int("Discussion", number).Err(err).Msg("Failed to reply")
+		return
+	}
+	log.Ctx(ctx).Info().Int("Discussion", number).Msgf("Replied with %d flagged term(s)", len(terms))
+}
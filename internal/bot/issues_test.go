@@ -0,0 +1,146 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/zendesk/term-check/internal/githubtest"
+	gh "github.com/zendesk/term-check/pkg/github"
+)
+
+// newIssueBot returns a bot flagging master, checking issues and discussions unless repos opt out
+func newIssueBot() *Bot {
+	return &Bot{
+		appSlug:             "term-check",
+		scanIssues:          true,
+		checkSuccessSummary: "All good.",
+		annotationBody:      "Flagged terms found: %s",
+		termPattern:         regexp.MustCompile("(?i)master"),
+		terms:               []term{{source: "(?i)master", pattern: regexp.MustCompile("(?i)master"), alternatives: []string{"main"}}},
+	}
+}
+
+type checkIssueTestCase struct {
+	name          string
+	issue         *github.Issue
+	changes       *github.EditChange
+	repoConfig    string
+	comments      string
+	expectedWrite string
+	expectedBody  string
+}
+
+func TestCheckIssue(t *testing.T) {
+	// The edit removing the flagged term from the title
+	renamed := &github.EditChange{}
+	json.Unmarshal([]byte(`{"title": {"from": "Rename master"}}`), renamed)
+	reply := `[{"id": 5, "user": {"login": "term-check[bot]", "type": "Bot"}, "body": "` + issueMarker + `"}]`
+
+	cases := []checkIssueTestCase{
+		{
+			name:          "RepliesWithFindings",
+			issue:         &github.Issue{Number: github.Int(1), Title: github.String("Rename master"), Body: github.String("")},
+			comments:      `[]`,
+			expectedWrite: "POST /repos/zendesk/term-check/issues/1/comments",
+			expectedBody:  "* `master` - consider main",
+		},
+		{
+			name:          "UpdatesReplyOnceClean",
+			issue:         &github.Issue{Number: github.Int(1), Title: github.String("Rename the branch"), Body: github.String("")},
+			changes:       renamed,
+			comments:      reply,
+			expectedWrite: "PATCH /repos/zendesk/term-check/issues/comments/5",
+			expectedBody:  "no flagged terms are used anymore",
+		},
+		{
+			name:          "NoReplyWhenClean",
+			issue:         &github.Issue{Number: github.Int(1), Title: github.String("Rename the branch"), Body: github.String("")},
+			comments:      `[]`,
+			expectedWrite: "",
+		},
+		{
+			name:          "RepoOptedOut",
+			issue:         &github.Issue{Number: github.Int(1), Title: github.String("Rename master"), Body: github.String("")},
+			repoConfig:    "scanIssues: false",
+			comments:      `[]`,
+			expectedWrite: "",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := githubtest.NewServer(t)
+			if tc.repoConfig != "" {
+				s.Respond("GET /repos/zendesk/term-check/contents/.github/term-check.yaml", http.StatusOK, githubtest.Contents(tc.repoConfig))
+			}
+			s.Respond("GET /repos/zendesk/term-check/issues/1/comments", http.StatusOK, tc.comments)
+			s.Respond("POST /repos/zendesk/term-check/issues/1/comments", http.StatusCreated, `{}`)
+			s.Respond("PATCH /repos/zendesk/term-check/issues/comments/5", http.StatusOK, `{}`)
+
+			newIssueBot().checkIssue(context.Background(), tc.issue, tc.changes, testRepo, s.Client)
+
+			var writes []string
+			for _, r := range s.Requests() {
+				if !strings.HasPrefix(r.Route, "GET ") {
+					writes = append(writes, r.Route)
+				}
+			}
+			if tc.expectedWrite == "" {
+				assert.Empty(t, writes)
+				return
+			}
+			if assert.Equal(t, []string{tc.expectedWrite}, writes) {
+				body := s.Body(tc.expectedWrite)["body"].(string)
+				assert.Contains(t, body, tc.expectedBody)
+				assert.Contains(t, body, issueMarker)
+			}
+		})
+	}
+}
+
+func TestCheckIssueWithoutTermsMakesNoRequests(t *testing.T) {
+	s := githubtest.NewServer(t)
+
+	issue := &github.Issue{Number: github.Int(1), Title: github.String("Rename the branch"), Body: github.String("")}
+	newIssueBot().checkIssue(context.Background(), issue, &github.EditChange{}, testRepo, s.Client)
+	d := &gh.Discussion{Number: github.Int(2), NodeID: github.String("D_2"), Title: github.String("Which branch?")}
+	newIssueBot().checkDiscussion(context.Background(), d, nil, testRepo, s.Client)
+
+	assert.Empty(t, s.Routes())
+}
+
+func TestCheckDiscussion(t *testing.T) {
+	s := githubtest.NewServer(t)
+	var mutations []map[string]interface{}
+	s.Handle("POST /graphql", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		if strings.HasPrefix(req["query"].(string), "mutation") {
+			mutations = append(mutations, req)
+			fmt.Fprint(w, `{"data": {}}`)
+			return
+		}
+		// Another bot's comment holding the marker is not the bot's reply
+		fmt.Fprintf(w, `{"data": {"repository": {"discussion": {"comments": {"nodes": [
+			{"id": "C_1", "body": %q, "author": {"__typename": "Bot", "login": "other-app"}},
+			{"id": "C_2", "body": %q, "author": {"__typename": "Bot", "login": "term-check"}}
+		]}}}}}`, issueMarker, issueMarker)
+	})
+
+	d := &gh.Discussion{Number: github.Int(1), NodeID: github.String("D_1"), Title: github.String("Where is master?")}
+	newIssueBot().checkDiscussion(context.Background(), d, nil, testRepo, s.Client)
+
+	if assert.Len(t, mutations, 1) {
+		assert.Contains(t, mutations[0]["query"], "updateDiscussionComment")
+		variables := mutations[0]["variables"].(map[string]interface{})
+		assert.Equal(t, "C_2", variables["id"])
+		assert.Contains(t, variables["body"], "`master`")
+	}
+}
@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/zendesk/term-check/internal/config"
+	"github.com/zendesk/term-check/pkg/lib"
+)
+
+// labelColor is the color given to labels the bot has to create
+const labelColor = "fbca04"
+
+// updateLabels adds the repo's configured labels to a pull request while it is flagged, and removes them once it is
+// not. Category labels are only kept while terms of their category are found.
+func (b *Bot) updateLabels(ctx context.Context, pr *github.PullRequest, r *github.Repository, ghc *github.Client, rc *config.RepoConfig, findings []finding, flagged bool) error {
+	lc := rc.Labels
+
+	configured := make(map[string]struct{})
+	wanted := make(map[string]struct{})
+	if lc.Findings != "" {
+		configured[lc.Findings] = struct{}{}
+		if flagged {
+			wanted[lc.Findings] = struct{}{}
+		}
+	}
+	for category, label := range lc.Categories {
+		configured[label] = struct{}{}
+		if flagged && b.foundCategory(findings, category) {
+			wanted[label] = struct{}{}
+		}
+	}
+	if len(configured) == 0 {
+		return nil
+	}
+
+	headSHA := pr.GetHead().GetSHA()
+	owner, name := r.GetOwner().GetLogin(), r.GetName()
+
+	current, _, err := ghc.Issues.ListLabelsByIssue(ctx, owner, name, pr.GetNumber(), &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return fmt.Errorf("Failed to list labels for %s: %s", headSHA, err)
+	}
+	present := make(map[string]struct{})
+	for _, l := range current {
+		present[l.GetName()] = struct{}{}
+	}
+
+	var add []string
+	for label := range wanted {
+		if lib.Contains(present, label) {
+			continue
+		}
+		if err := ensureLabel(ctx, r, ghc, label); err != nil {
+			return err
+		}
+		add = append(add, label)
+	}
+	if len(add) > 0 {
+		if _, _, err := ghc.Issues.AddLabelsToIssue(ctx, owner, name, pr.GetNumber(), add); err != nil {
+			return fmt.Errorf("Failed to add labels for %s: %s", headSHA, err)
+		}
+	}
+
+	for label := range configured {
+		if lib.Contains(present, label) && !lib.Contains(wanted, label) {
+			if _, err := ghc.Issues.RemoveLabelForIssue(ctx, owner, name, pr.GetNumber(), label); err != nil {
+				return fmt.Errorf("Failed to remove label %s for %s: %s", label, headSHA, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// foundCategory returns whether any of the terms in the findings belong to the passed in category
+func (b *Bot) foundCategory(findings []finding, category string) bool {
+	for _, f := range findings {
+		for _, m := range f.terms {
+			if t, ok := b.lookupTerm(m); ok && t.category == category {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ensureLabel creates a label in the repo if it doesn't exist yet
+func ensureLabel(ctx context.Context, r *github.Repository, ghc *github.Client, label string) error {
+	owner, name := r.GetOwner().GetLogin(), r.GetName()
+
+	_, resp, err := ghc.Issues.GetLabel(ctx, owner, name, label)
+	if err == nil {
+		return nil
+	}
+	if resp == nil || resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("Failed to get label %s: %s", label, err)
+	}
+
+	_, _, err = ghc.Issues.CreateLabel(ctx, owner, name, &github.Label{
+		Name:  github.String(label),
+		Color: github.String(labelColor),
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to create label %s: %s", label, err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/zendesk/term-check/internal/config"
+	"github.com/zendesk/term-check/internal/githubtest"
+)
+
+type updateLabelsTestCase struct {
+	name             string
+	labels           config.LabelConfig
+	present          string
+	findings         []finding
+	expectedRequests []string
+	expectedAdded    []string
+}
+
+func TestUpdateLabels(t *testing.T) {
+	b := &Bot{terms: []term{
+		{source: "master", pattern: regexp.MustCompile("master"), category: "legacy"},
+		{source: "slave", pattern: regexp.MustCompile("slave"), category: "offensive"},
+	}}
+	labels := config.LabelConfig{Findings: "terms", Categories: map[string]string{"legacy": "legacy-terms", "offensive": "offensive-terms"}}
+
+	cases := []updateLabelsTestCase{
+		{
+			name:             "NoLabelsConfigured",
+			findings:         []finding{{terms: []string{"master"}}},
+			expectedRequests: nil,
+		},
+		{
+			name:     "AddsLabelsOfFoundCategories",
+			labels:   labels,
+			present:  `[{"name": "terms"}]`,
+			findings: []finding{{terms: []string{"master"}}},
+			expectedRequests: []string{
+				"GET /repos/zendesk/term-check/issues/1/labels",
+				"GET /repos/zendesk/term-check/labels/legacy-terms",
+				"POST /repos/zendesk/term-check/labels",
+				"POST /repos/zendesk/term-check/issues/1/labels",
+			},
+			expectedAdded: []string{"legacy-terms"},
+		},
+		{
+			name:     "RemovesLabelsOfCategoriesNoLongerFound",
+			labels:   labels,
+			present:  `[{"name": "terms"}, {"name": "legacy-terms"}, {"name": "offensive-terms"}, {"name": "bug"}]`,
+			findings: []finding{{terms: []string{"slave"}}},
+			expectedRequests: []string{
+				"GET /repos/zendesk/term-check/issues/1/labels",
+				"DELETE /repos/zendesk/term-check/issues/1/labels/legacy-terms",
+			},
+		},
+		{
+			name:     "RemovesAllLabelsOnceClean",
+			labels:   config.LabelConfig{Findings: "terms"},
+			present:  `[{"name": "terms"}, {"name": "bug"}]`,
+			findings: nil,
+			expectedRequests: []string{
+				"GET /repos/zendesk/term-check/issues/1/labels",
+				"DELETE /repos/zendesk/term-check/issues/1/labels/terms",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := githubtest.NewServer(t)
+			s.Respond("GET /repos/zendesk/term-check/issues/1/labels", http.StatusOK, tc.present)
+			s.Respond("POST /repos/zendesk/term-check/labels", http.StatusCreated, `{}`)
+			s.Respond("DELETE /repos/zendesk/term-check/issues/1/labels/legacy-terms", http.StatusOK, `[]`)
+			s.Respond("DELETE /repos/zendesk/term-check/issues/1/labels/terms", http.StatusOK, `[]`)
+			var added []string
+			s.Handle("POST /repos/zendesk/term-check/issues/1/labels", func(w http.ResponseWriter, r *http.Request) {
+				json.NewDecoder(r.Body).Decode(&added)
+				w.Write([]byte(`[]`))
+			})
+
+			pr := &github.PullRequest{Number: github.Int(1)}
+			rc := &config.RepoConfig{Labels: tc.labels}
+			err := b.updateLabels(context.Background(), pr, testRepo, s.Client, rc, tc.findings, len(tc.findings) > 0)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedRequests, s.Routes())
+			assert.Equal(t, tc.expectedAdded, added)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package bot
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// cellReplacer escapes what would end a cell of a Markdown table or the table itself
+var cellReplacer = strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ", "\r", " ")
+
+// tableCell escapes text to be put in a cell of a Markdown table
+func tableCell(s string) string {
+	return cellReplacer.Replace(s)
+}
+
+// codeSpan returns text as an inline code span that can be put in a cell of a Markdown table. The span is fenced with
+// more backticks than the longest run of them in the text, and pipes are escaped, as tables split cells on them even
+// inside code spans.
+func codeSpan(s string) string {
+	longest, run := 0, 0
+	for _, c := range s {
+		if c == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+
+	fence := strings.Repeat("`", longest+1)
+	// A space keeps backticks at the ends of the text from joining the fence, and is stripped when rendered
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + tableCell(s) + fence
+}
+
+// blobURL returns the URL of a line of a file at a ref in a repository, escaping each segment of the file's path
+func blobURL(repoURL, ref, path string, line int) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return fmt.Sprintf("%s/blob/%s/%s#L%d", repoURL, url.PathEscape(ref), strings.Join(segments, "/"), line)
+}
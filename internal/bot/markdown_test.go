@@ -0,0 +1,57 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type markdownTestCase struct {
+	name     string
+	text     string
+	expected string
+}
+
+func TestTableCell(t *testing.T) {
+	cases := []markdownTestCase{
+		{name: "Plain", text: "main, primary", expected: "main, primary"},
+		{name: "Pipe", text: "a|b", expected: `a\|b`},
+		{name: "Newlines", text: "a\nb\r\nc", expected: "a b c"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tableCell(tc.text))
+		})
+	}
+}
+
+func TestCodeSpan(t *testing.T) {
+	cases := []markdownTestCase{
+		{name: "Plain", text: "README.md", expected: "`README.md`"},
+		{name: "Backtick", text: "a`b", expected: "``a`b``"},
+		{name: "BacktickRun", text: "a``b`c", expected: "```a``b`c```"},
+		{name: "BacktickAtEnds", text: "`a`", expected: "`` `a` ``"},
+		{name: "Pipe", text: "a|b.md", expected: "`a\\|b.md`"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, codeSpan(tc.text))
+		})
+	}
+}
+
+func TestBlobURL(t *testing.T) {
+	cases := []markdownTestCase{
+		{name: "Plain", text: "docs/README.md", expected: "https://github.com/zendesk/term-check/blob/abc/docs/README.md#L3"},
+		{name: "Spaces", text: "my docs/a b.md", expected: "https://github.com/zendesk/term-check/blob/abc/my%20docs/a%20b.md#L3"},
+		{name: "Markdown", text: "a)b/c#d?.md", expected: "https://github.com/zendesk/term-check/blob/abc/a%29b/c%23d%3F.md#L3"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, blobURL("https://github.com/zendesk/term-check", "abc", tc.text, 3))
+		})
+	}
+}
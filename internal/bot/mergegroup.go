@@ -0,0 +1,270 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/rs/zerolog/log"
+	"github.com/zendesk/term-check/internal/config"
+	gh "github.com/zendesk/term-check/pkg/github"
+	"github.com/zendesk/term-check/pkg/lib"
+)
+
+const mergeQueueQuery = `query($owner: String!, $name: String!, $branch: String!) {
+  repository(owner: $owner, name: $name) {
+    mergeQueue(branch: $branch) {
+      entries(first: 100) { nodes { position headCommit { oid } pullRequest { number } } }
+    }
+  }
+}`
+
+// mergeGroupRefPattern matches the branch merge queues create for a group, e.x.
+// refs/heads/gh-readonly-queue/main/pr-123-<sha>, capturing the number of the pull request at its head
+var mergeGroupRefPattern = regexp.MustCompile(`gh-readonly-queue/.+/pr-(\d+)-[0-9a-f]+$`)
+
+// createMergeGroupCheckRun checks the changes of a merge group, from its base to its head, and reports a check run on
+// the group's head commit
+func (b *Bot) createMergeGroupCheckRun(ctx context.Context, mg *gh.MergeGroup, r *github.Repository, ghc *github.Client) {
+	if b.tokenMode() {
+		b.createMergeGroupStatus(ctx, mg, r, ghc)
+		return
+	}
+
+	headSHA := mg.GetHeadSHA()
+
+	log.Ctx(ctx).Info().Str("SHA", headSHA).Msg("Creating CheckRun...")
+
+	cr, err := b.startCheckRun(ctx, r, headSHA, ghc)
+	if err != nil {
+		log.Ctx(ctx).Error().Str("SHA", headSHA).Err(err).Msgf("Failed to POST CheckRun")
+		return
+	}
+
+	cro, err := b.checkMergeGroup(ctx, mg, r, ghc)
+	if err != nil {
+		b.failCheckRun(ctx, cr, r, ghc, err)
+		return
+	}
+	b.completeCheckRun(ctx, cr, r, ghc, cro)
+}
+
+// createMergeGroupStatus checks a merge group in the same way as createMergeGroupCheckRun, reporting the result as a
+// commit status named after the check, which merge queues can require like a check run
+func (b *Bot) createMergeGroupStatus(ctx context.Context, mg *gh.MergeGroup, r *github.Repository, ghc *github.Client) {
+	headSHA := mg.GetHeadSHA()
+	targetURL := r.GetHTMLURL()
+
+	log.Ctx(ctx).Info().Str("SHA", headSHA).Msg("Creating Status...")
+
+	if err := b.setStatus(ctx, r, headSHA, b.checkName, "pending", fmt.Sprintf("%s is running", b.checkName), targetURL, ghc); err != nil {
+		log.Ctx(ctx).Error().Str("SHA", headSHA).Err(err).Msg("Failed to POST Status")
+		return
+	}
+
+	cro, err := b.checkMergeGroup(ctx, mg, r, ghc)
+	if err != nil {
+		b.failStatus(ctx, r, headSHA, targetURL, ghc, err)
+		return
+	}
+
+	if err := b.setStatus(ctx, r, headSHA, b.checkName, statusStates[cro.GetConclusion()], cro.Output.GetSummary(), targetURL, ghc); err != nil {
+		log.Ctx(ctx).Error().Str("SHA", headSHA).Err(err).Msg("Failed to complete Status")
+		return
+	}
+	log.Ctx(ctx).Info().Str("SHA", headSHA).Msg("Successfully created Status")
+}
+
+// checkMergeGroup checks the changes of a merge group, returning the result to report. Exemptions of the pull requests
+// in the group are honored for the lines they introduced.
+func (b *Bot) checkMergeGroup(ctx context.Context, mg *gh.MergeGroup, r *github.Repository, ghc *github.Client) (github.UpdateCheckRunOptions, error) {
+	headSHA := mg.GetHeadSHA()
+
+	rc, oc, err := getConfigs(ctx, r, headSHA, ghc)
+	if err != nil {
+		return github.UpdateCheckRunOptions{}, err
+	}
+
+	diff, err := compareDiff(ctx, r, mg.GetBaseSHA(), headSHA, ghc)
+	if err != nil {
+		return github.UpdateCheckRunOptions{}, err
+	}
+	sc, err := b.scanDiff(diff, headSHA, rc)
+	if err != nil {
+		return github.UpdateCheckRunOptions{}, err
+	}
+
+	numbers := b.mergeGroupPullRequests(ctx, mg, r, ghc)
+	exempted, err := b.mergeGroupExemptions(ctx, numbers, r, ghc, rc)
+	if err != nil {
+		return github.UpdateCheckRunOptions{}, err
+	}
+	findings := filterExempted(sc.findings, exempted)
+
+	cro := b.checkRunResult(findings, rc, oc, &exemptions{}, r.GetHTMLURL())
+	// Actions work on pull requests, which merge group check runs don't belong to
+	cro.Actions = nil
+	return cro, nil
+}
+
+// mergeGroupPullRequests returns the numbers of the pull requests whose changes are in a merge group, which are the
+// group's own entry in the merge queue and every entry ahead of it. Falls back to the pull request named by the group's
+// head ref when the queue can't be read.
+func (b *Bot) mergeGroupPullRequests(ctx context.Context, mg *gh.MergeGroup, r *github.Repository, ghc *github.Client) []int {
+	headSHA := mg.GetHeadSHA()
+
+	var fallback []int
+	if m := mergeGroupRefPattern.FindStringSubmatch(mg.GetHeadRef()); m != nil {
+		number, _ := strconv.Atoi(m[1])
+		fallback = []int{number}
+	}
+
+	var data struct {
+		Repository struct {
+			MergeQueue struct {
+				Entries struct {
+					Nodes []struct {
+						Position   int `json:"position"`
+						HeadCommit struct {
+							OID string `json:"oid"`
+						} `json:"headCommit"`
+						PullRequest struct {
+							Number int `json:"number"`
+						} `json:"pullRequest"`
+					} `json:"nodes"`
+				} `json:"entries"`
+			} `json:"mergeQueue"`
+		} `json:"repository"`
+	}
+	vars := map[string]interface{}{
+		"owner":  r.GetOwner().GetLogin(),
+		"name":   r.GetName(),
+		"branch": strings.TrimPrefix(mg.GetBaseRef(), "refs/heads/"),
+	}
+	if err := gh.GraphQL(ctx, ghc, mergeQueueQuery, vars, &data); err != nil {
+		log.Ctx(ctx).Warn().Str("SHA", headSHA).Err(err).Msg("Failed to get merge queue, only honoring exemptions of the head pull request")
+		return fallback
+	}
+	entries := data.Repository.MergeQueue.Entries.Nodes
+
+	position := 0
+	for _, e := range entries {
+		if e.HeadCommit.OID == headSHA || (len(fallback) > 0 && e.PullRequest.Number == fallback[0]) {
+			position = e.Position
+			break
+		}
+	}
+	if position == 0 {
+		return fallback
+	}
+
+	var numbers []int
+	for _, e := range entries {
+		if e.Position <= position {
+			numbers = append(numbers, e.PullRequest.Number)
+		}
+	}
+	return numbers
+}
+
+// mergeGroupExemptions returns the keys of the findings exempted in the pull requests of a merge group. Pull requests
+// are scanned one by one to tell which lines each introduced, and lines also introduced by a pull request without the
+// exemption are left out, so exemptions don't spill over to other pull requests.
+func (b *Bot) mergeGroupExemptions(ctx context.Context, numbers []int, r *github.Repository, ghc *github.Client, rc *config.RepoConfig) (map[string]struct{}, error) {
+	prs := make([]*github.PullRequest, 0, len(numbers))
+	exs := make([]*exemptions, 0, len(numbers))
+	exempting := false
+	for _, n := range numbers {
+		pr := &github.PullRequest{Number: github.Int(n)}
+		ex, err := b.getExemptions(ctx, pr, r, ghc)
+		if err != nil {
+			return nil, err
+		}
+		prs, exs = append(prs, pr), append(exs, ex)
+		exempting = exempting || ex.pr || len(ex.terms) > 0
+	}
+	if !exempting {
+		return nil, nil
+	}
+
+	exempted := make(map[string]struct{})
+	kept := make(map[string]struct{})
+	for i, pr := range prs {
+		sc, err := b.scanPullRequest(ctx, pr, r, ghc, rc)
+		if err != nil {
+			return nil, err
+		}
+
+		remaining := make(map[string]struct{})
+		if !exs[i].pr {
+			for _, f := range b.filter(sc.findings, exs[i]) {
+				for _, m := range f.terms {
+					remaining[exemptionKey(f, m)] = struct{}{}
+				}
+			}
+		}
+		for _, f := range sc.findings {
+			for _, m := range f.terms {
+				if k := exemptionKey(f, m); lib.Contains(remaining, k) {
+					kept[k] = struct{}{}
+				} else {
+					exempted[k] = struct{}{}
+				}
+			}
+		}
+	}
+
+	for k := range kept {
+		delete(exempted, k)
+	}
+	return exempted, nil
+}
+
+// filterExempted returns the passed in findings without the terms whose keys are exempted
+func filterExempted(findings []finding, exempted map[string]struct{}) []finding {
+	if len(exempted) == 0 {
+		return findings
+	}
+
+	var filtered []finding
+	for _, f := range findings {
+		var terms []string
+		for _, m := range f.terms {
+			if !lib.Contains(exempted, exemptionKey(f, m)) {
+				terms = append(terms, m)
+			}
+		}
+		if len(terms) > 0 {
+			f.terms = terms
+			filtered = append(filtered, f)
+		}
+	}
+	return filtered
+}
+
+// exemptionKey identifies a term used on an added line across diffs, which number lines differently
+func exemptionKey(f finding, term string) string {
+	return fmt.Sprintf("%s:%s:%s", f.path, term, f.content)
+}
+
+// compareDiff returns the raw diff between two commits of a repository
+func compareDiff(ctx context.Context, r *github.Repository, base, head string, ghc *github.Client) (string, error) {
+	u := fmt.Sprintf("repos/%s/%s/compare/%s...%s", r.GetOwner().GetLogin(), r.GetName(), base, head)
+	req, err := ghc.NewRequest("GET", u, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3.diff")
+
+	var diff bytes.Buffer
+	resp, err := ghc.Do(ctx, req, &diff)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Failed to get diff for %s...%s: %s", base, head, err)
+	}
+	return diff.String(), nil
+}
@@ -0,0 +1,145 @@
+package bot
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/zendesk/term-check/internal/githubtest"
+	gh "github.com/zendesk/term-check/pkg/github"
+)
+
+const mergeGroupDiff = `diff --git a/README.md b/README.md
+index 1111111..2222222 100644
+--- a/README.md
++++ b/README.md
+@@ -1,1 +1,2 @@
+ # Term Check
++Use the master branch
+`
+
+// newFakeMergeGroupAPI starts a fake GitHub API serving the changes of a merge group from base to abc
+func newFakeMergeGroupAPI(t *testing.T) *githubtest.Server {
+	s := newFakeChecks(t, `{"total_count": 0, "check_runs": []}`, "[]")
+	s.Respond("GET /repos/zendesk/term-check/compare/base...abc", http.StatusOK, mergeGroupDiff)
+	s.Respond("GET /repos/zendesk/term-check/issues/1/comments", http.StatusOK, `[]`)
+	s.Respond("POST /repos/zendesk/term-check/statuses/abc", http.StatusCreated, `{}`)
+	return s
+}
+
+func TestCreateMergeGroupCheckRunInTokenMode(t *testing.T) {
+	s := newFakeMergeGroupAPI(t)
+
+	b := &Bot{
+		withToken:           true,
+		tokenLogin:          "term-check-user",
+		checkName:           "term-check",
+		checkFailureSummary: "Flagged terms found.",
+		mode:                "blocking",
+		termPattern:         regexp.MustCompile("master"),
+		terms:               []term{{source: "master", pattern: regexp.MustCompile("master")}},
+	}
+	mg := &gh.MergeGroup{
+		HeadSHA: github.String("abc"),
+		HeadRef: github.String("refs/heads/gh-readonly-queue/main/pr-1-abc"),
+		BaseSHA: github.String("base"),
+	}
+	b.createMergeGroupCheckRun(context.Background(), mg, testRepo, s.Client)
+
+	// The merge queue waits on a status named after the check, as there is no check run
+	var states []interface{}
+	for _, r := range s.Requests() {
+		assert.NotEqual(t, "POST /repos/zendesk/term-check/check-runs", r.Route)
+		if r.Route == "POST /repos/zendesk/term-check/statuses/abc" {
+			assert.Equal(t, "term-check", r.Body["context"])
+			states = append(states, r.Body["state"])
+		}
+	}
+	assert.Equal(t, []interface{}{"pending", "failure"}, states)
+}
+
+// prDiff returns the diff of a pull request adding the passed in line to the README
+func prDiff(line string) string {
+	return `diff --git a/README.md b/README.md
+index 1111111..2222222 100644
+--- a/README.md
++++ b/README.md
+@@ -1,1 +1,2 @@
+ # Term Check
++` + line + "\n"
+}
+
+type checkMergeGroupTestCase struct {
+	name             string
+	secondPRDiff     string
+	expectedMessages []string
+}
+
+func TestCheckMergeGroup(t *testing.T) {
+	ignoredMaster := `[{"user": {"login": "term-check[bot]", "type": "Bot"}, "body": "<!-- term-check:ignore-term by=alice term=master -->"}]`
+	// The group of the second pull request in the queue holds the first one too, but not the third
+	queue := `{"data": {"repository": {"mergeQueue": {"entries": {"nodes": [
+		{"position": 1, "headCommit": {"oid": "def"}, "pullRequest": {"number": 1}},
+		{"position": 2, "headCommit": {"oid": "abc"}, "pullRequest": {"number": 2}},
+		{"position": 3, "headCommit": {"oid": "ghi"}, "pullRequest": {"number": 3}}
+	]}}}}}`
+	groupDiff := mergeGroupDiff + "@@ -10,1 +11,2 @@\n line\n+a slave process\n"
+
+	cases := []checkMergeGroupTestCase{
+		{
+			name:             "HonorsExemptionsOfEveryPullRequest",
+			secondPRDiff:     prDiff("a slave process"),
+			expectedMessages: []string{"Found slave"},
+		},
+		{
+			name:             "KeepsLinesAlsoAddedWithoutExemption",
+			secondPRDiff:     prDiff("Use the master branch") + "@@ -10,1 +11,2 @@\n line\n+a slave process\n",
+			expectedMessages: []string{"Found master", "Found slave"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := githubtest.NewServer(t)
+			s.Respond("GET /repos/zendesk/term-check/compare/base...abc", http.StatusOK, groupDiff)
+			s.Respond("POST /graphql", http.StatusOK, queue)
+			s.Respond("GET /repos/zendesk/term-check/issues/1/comments", http.StatusOK, ignoredMaster)
+			s.Respond("GET /repos/zendesk/term-check/issues/2/comments", http.StatusOK, `[]`)
+			s.Respond("GET /repos/zendesk/term-check/pulls/1", http.StatusOK, prDiff("Use the master branch"))
+			s.Respond("GET /repos/zendesk/term-check/pulls/2", http.StatusOK, tc.secondPRDiff)
+
+			b := &Bot{
+				appSlug:        "term-check",
+				checkName:      "term-check",
+				annotationBody: "Found %s",
+				mode:           "blocking",
+				termPattern:    regexp.MustCompile("master|slave"),
+				terms: []term{
+					{source: "master", pattern: regexp.MustCompile("master")},
+					{source: "slave", pattern: regexp.MustCompile("slave")},
+				},
+			}
+			mg := &gh.MergeGroup{
+				HeadSHA: github.String("abc"),
+				HeadRef: github.String("refs/heads/gh-readonly-queue/main/pr-2-abc"),
+				BaseSHA: github.String("base"),
+				BaseRef: github.String("refs/heads/main"),
+			}
+			cro, err := b.checkMergeGroup(context.Background(), mg, testRepo, s.Client)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			var messages []string
+			for _, a := range cro.Output.Annotations {
+				messages = append(messages, a.GetMessage())
+			}
+			assert.Equal(t, tc.expectedMessages, messages)
+			assert.Equal(t, "main", s.Body("POST /graphql")["variables"].(map[string]interface{})["branch"])
+			assert.NotContains(t, s.Routes(), "GET /repos/zendesk/term-check/issues/3/comments")
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package bot
+
+import (
+	"github.com/zendesk/term-check/internal/config"
+)
+
+var (
+	// modeRanks orders the enforcement modes from least to most strict
+	modeRanks = map[string]int{
+		config.AdvisoryMode:       0,
+		config.ActionRequiredMode: 1,
+		config.BlockingMode:       2,
+	}
+	// modeConclusions maps each enforcement mode to the conclusion of check runs with findings
+	modeConclusions = map[string]string{
+		config.AdvisoryMode:       "neutral",
+		config.ActionRequiredMode: "action_required",
+		config.BlockingMode:       "failure",
+	}
+)
+
+// enforcementMode returns the mode to enforce on a repo. The repo's own mode wins over the bot's default, but never
+// goes below the minimum mode set by its organization.
+func (b *Bot) enforcementMode(rc *config.RepoConfig, oc *config.OrgConfig) string {
+	mode := b.mode
+	if config.ValidMode(rc.Mode) {
+		mode = rc.Mode
+	}
+
+	if config.ValidMode(oc.MinimumMode) && modeRanks[oc.MinimumMode] > modeRanks[mode] {
+		mode = oc.MinimumMode
+	}
+
+	return mode
+}
@@ -0,0 +1,80 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zendesk/term-check/internal/config"
+)
+
+type enforcementModeTestCase struct {
+	name        string
+	botMode     string
+	repoMode    string
+	minimumMode string
+	expected    string
+}
+
+func TestEnforcementMode(t *testing.T) {
+	cases := []enforcementModeTestCase{
+		{
+			name:     "BotDefault",
+			botMode:  config.AdvisoryMode,
+			expected: config.AdvisoryMode,
+		},
+		{
+			name:     "RepoOverridesBot",
+			botMode:  config.AdvisoryMode,
+			repoMode: config.BlockingMode,
+			expected: config.BlockingMode,
+		},
+		{
+			name:        "RepoBelowFloor",
+			botMode:     config.AdvisoryMode,
+			repoMode:    config.AdvisoryMode,
+			minimumMode: config.ActionRequiredMode,
+			expected:    config.ActionRequiredMode,
+		},
+		{
+			name:        "RepoAtFloor",
+			botMode:     config.AdvisoryMode,
+			repoMode:    config.ActionRequiredMode,
+			minimumMode: config.ActionRequiredMode,
+			expected:    config.ActionRequiredMode,
+		},
+		{
+			name:        "RepoAboveFloor",
+			botMode:     config.AdvisoryMode,
+			repoMode:    config.BlockingMode,
+			minimumMode: config.ActionRequiredMode,
+			expected:    config.BlockingMode,
+		},
+		{
+			name:        "BotDefaultBelowFloor",
+			botMode:     config.AdvisoryMode,
+			minimumMode: config.BlockingMode,
+			expected:    config.BlockingMode,
+		},
+		{
+			name:     "InvalidRepoModeFallsBackToBot",
+			botMode:  config.ActionRequiredMode,
+			repoMode: "strict",
+			expected: config.ActionRequiredMode,
+		},
+		{
+			name:        "InvalidFloorIsIgnored",
+			botMode:     config.AdvisoryMode,
+			repoMode:    config.AdvisoryMode,
+			minimumMode: "strict",
+			expected:    config.AdvisoryMode,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := &Bot{mode: tc.botMode}
+			mode := b.enforcementMode(&config.RepoConfig{Mode: tc.repoMode}, &config.OrgConfig{MinimumMode: tc.minimumMode})
+			assert.Equal(t, tc.expected, mode)
+		})
+	}
+}
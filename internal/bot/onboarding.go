@@ -0,0 +1,278 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/rs/zerolog/log"
+	ignore "github.com/sabhiram/go-gitignore"
+	"github.com/zendesk/term-check/internal/config"
+	"github.com/zendesk/term-check/pkg/extract"
+	"github.com/zendesk/term-check/pkg/lib"
+)
+
+const (
+	onboardingBranch = "term-check/onboarding"
+	// onboardingMaxFiles caps how many files are scanned for the summary of existing usages. Each file is a request, and
+	// installing on all repositories onboards every repo from a single event within the installation's quota.
+	onboardingMaxFiles = 100
+	// onboardingMaxFileSize skips files too big to be worth scanning, in bytes
+	onboardingMaxFileSize = 512 * 1024
+	// binarySniffLength is how much of a file is looked at for NUL bytes, as git does to tell binary files apart
+	binarySniffLength = 8000
+)
+
+var (
+	// vendoredDirs holds directory names that usually contain vendored or generated code
+	vendoredDirs = map[string]struct{}{
+		"vendor":           {},
+		"node_modules":     {},
+		"bower_components": {},
+		"third_party":      {},
+		"third-party":      {},
+		"Godeps":           {},
+		"dist":             {},
+	}
+	// generatedFilePatterns holds .gitignore patterns of files that are usually generated
+	generatedFilePatterns = []string{
+		"*.pb.go",
+		"*_generated.go",
+		"*.gen.go",
+		"*.min.js",
+		"*.min.css",
+		"*.lock",
+		"package-lock.json",
+		"go.sum",
+	}
+	// binaryExtensions holds extensions of files that are usually binary, skipped without being downloaded
+	binaryExtensions = map[string]struct{}{
+		".png": {}, ".jpg": {}, ".jpeg": {}, ".gif": {}, ".ico": {}, ".bmp": {}, ".webp": {},
+		".pdf": {}, ".zip": {}, ".gz": {}, ".tgz": {}, ".bz2": {}, ".xz": {}, ".7z": {}, ".jar": {}, ".war": {},
+		".exe": {}, ".dll": {}, ".so": {}, ".dylib": {}, ".a": {}, ".o": {}, ".class": {}, ".pyc": {}, ".bin": {},
+		".woff": {}, ".woff2": {}, ".ttf": {}, ".otf": {}, ".eot": {},
+		".mp3": {}, ".mp4": {}, ".mov": {}, ".avi": {}, ".wav": {}, ".ogg": {},
+	}
+)
+
+// onboardRepos opens an onboarding pull request on every passed in repo that doesn't have a configuration file yet
+func (b *Bot) onboardRepos(ctx context.Context, owner string, repos []*github.Repository, ghc *github.Client) {
+	for _, repo := range repos {
+		if err := b.onboardRepo(ctx, owner, repo.GetName(), ghc); err != nil {
+			log.Ctx(ctx).Error().Str("Repo", repo.GetFullName()).Err(err).Msg("Failed to open onboarding pull request")
+		}
+	}
+}
+
+// onboardRepo opens a pull request adding a starter configuration file to a repo. The file ignores the vendored and
+// generated paths found on the default branch, and the pull request summarizes the usages of flagged terms already
+// there.
+func (b *Bot) onboardRepo(ctx context.Context, owner, name string, ghc *github.Client) error {
+	r, resp, err := ghc.Repositories.Get(ctx, owner, name)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Failed to get repo: %s", err)
+	}
+	base := r.GetDefaultBranch()
+
+	if _, _, resp, _ := ghc.Repositories.GetContents(ctx, owner, name, config.RepoConfigPath, &github.RepositoryContentGetOptions{Ref: base}); resp != nil && resp.StatusCode == http.StatusOK {
+		log.Ctx(ctx).Info().Str("Repo", r.GetFullName()).Msg("Repo already configured, skipping onboarding")
+		return nil
+	}
+	if _, resp, _ := ghc.Git.GetRef(ctx, owner, name, "heads/"+onboardingBranch); resp != nil && resp.StatusCode == http.StatusOK {
+		log.Ctx(ctx).Info().Str("Repo", r.GetFullName()).Msg("Onboarding branch already exists, skipping onboarding")
+		return nil
+	}
+
+	ref, _, err := ghc.Git.GetRef(ctx, owner, name, "heads/"+base)
+	if err != nil {
+		// Empty repos have no default branch to branch off of yet
+		return fmt.Errorf("Failed to get %s: %s", base, err)
+	}
+	headSHA := ref.GetObject().GetSHA()
+
+	tree, _, err := ghc.Git.GetTree(ctx, owner, name, headSHA, true)
+	if err != nil {
+		return fmt.Errorf("Failed to get tree of %s: %s", base, err)
+	}
+
+	ignores := detectIgnores(tree.Entries)
+	usages, scanned, eligible := b.countUsages(ctx, owner, name, tree, ignores, ghc)
+
+	_, _, err = ghc.Git.CreateRef(ctx, owner, name, &github.Reference{
+		Ref:    github.String("refs/heads/" + onboardingBranch),
+		Object: &github.GitObject{SHA: github.String(headSHA)},
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to create branch %s: %s", onboardingBranch, err)
+	}
+
+	_, _, err = ghc.Repositories.CreateFile(ctx, owner, name, config.RepoConfigPath, &github.RepositoryContentFileOptions{
+		Message: github.String(fmt.Sprintf("Add %s configuration", b.checkName)),
+		Content: []byte(b.starterConfig(ignores)),
+		Branch:  github.String(onboardingBranch),
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to commit %s: %s", config.RepoConfigPath, err)
+	}
+
+	_, _, err = ghc.PullRequests.Create(ctx, owner, name, &github.NewPullRequest{
+		Title: github.String(fmt.Sprintf("Configure %s", b.checkName)),
+		Head:  github.String(onboardingBranch),
+		Base:  github.String(base),
+		Body:  github.String(b.onboardingBody(base, ignores, usages, scanned, eligible)),
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to open pull request: %s", err)
+	}
+
+	log.Ctx(ctx).Info().Str("Repo", r.GetFullName()).Msg("Opened onboarding pull request")
+	return nil
+}
+
+// detectIgnores returns .gitignore patterns for the vendored directories and generated files found in a tree
+func detectIgnores(entries []*github.TreeEntry) []string {
+	var ignores []string
+	seen := make(map[string]struct{})
+	add := func(p string) {
+		if !lib.Contains(seen, p) {
+			seen[p] = struct{}{}
+			ignores = append(ignores, p)
+		}
+	}
+
+	for _, e := range entries {
+		p := e.GetPath()
+		if e.GetType() == "tree" {
+			if lib.Contains(vendoredDirs, path.Base(p)) {
+				add(p + "/")
+			}
+			continue
+		}
+		for _, pattern := range generatedFilePatterns {
+			if ok, _ := path.Match(pattern, path.Base(p)); ok {
+				add(pattern)
+			}
+		}
+	}
+
+	sort.Strings(ignores)
+	return ignores
+}
+
+// countUsages counts the flagged terms used in the files of a tree, by configured term. Returns the counts along with
+// the number of files scanned, which stops at onboardingMaxFiles, and the number of files that could have been.
+// Files that fail to download are skipped.
+func (b *Bot) countUsages(ctx context.Context, owner, name string, tree *github.Tree, ignores []string, ghc *github.Client) (map[string]int, int, int) {
+	usages := make(map[string]int)
+	matcher := ignore.CompileIgnoreLines(ignores...)
+
+	scanned, eligible := 0, 0
+	for _, e := range tree.Entries {
+		if e.GetType() != "blob" || e.GetSize() > onboardingMaxFileSize || matcher.MatchesPath(e.GetPath()) {
+			continue
+		}
+		if _, ok := binaryExtensions[strings.ToLower(path.Ext(e.GetPath()))]; ok {
+			continue
+		}
+		eligible++
+		if eligible > onboardingMaxFiles || ctx.Err() != nil {
+			continue
+		}
+
+		blob, _, err := ghc.Git.GetBlobRaw(ctx, owner, name, e.GetSHA())
+		if err != nil {
+			log.Ctx(ctx).Warn().Str("Repo", owner+"/"+name).Str("Path", e.GetPath()).Err(err).Msg("Failed to get file, skipping")
+			continue
+		}
+		if isBinary(blob) {
+			eligible--
+			continue
+		}
+		scanned++
+
+		var lines []extract.Line
+		for i, l := range strings.Split(string(blob), "\n") {
+			lines = append(lines, extract.Line{Number: i + 1, Content: l})
+		}
+		for _, f := range b.matchLines(e.GetPath(), lines) {
+			for _, m := range f.terms {
+				if t, ok := b.lookupTerm(m); ok {
+					usages[t.source]++
+				}
+			}
+		}
+	}
+
+	return usages, scanned, eligible
+}
+
+// isBinary returns whether file contents are binary rather than text, taken to be the case when a NUL byte is found
+// within their first few kilobytes
+func isBinary(content []byte) bool {
+	if len(content) > binarySniffLength {
+		content = content[:binarySniffLength]
+	}
+	return bytes.IndexByte(content, 0) != -1
+}
+
+// starterConfig renders the configuration file committed by the onboarding pull request
+func (b *Bot) starterConfig(ignores []string) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "# Configuration for %s\n", b.checkName)
+	fmt.Fprint(&sb, "# An array of patterns following .gitignore rules (http://git-scm.com/docs/gitignore) specifying which files and\n")
+	fmt.Fprint(&sb, "# directories should be ignored by the app\n")
+	if len(ignores) == 0 {
+		fmt.Fprint(&sb, "ignore: []\n")
+		return sb.String()
+	}
+
+	fmt.Fprint(&sb, "ignore:\n")
+	for _, i := range ignores {
+		fmt.Fprintf(&sb, "  - %q\n", i)
+	}
+	return sb.String()
+}
+
+// onboardingBody renders the description of the onboarding pull request
+func (b *Bot) onboardingBody(base string, ignores []string, usages map[string]int, scanned, eligible int) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "%s is now installed on this repository! It checks pull requests for exclusionary language.\n\n", b.checkName)
+	fmt.Fprintf(&sb, "This pull request adds a starter `%s`. Merge it as is, or adjust it first.\n\n", config.RepoConfigPath)
+
+	if len(ignores) > 0 {
+		fmt.Fprint(&sb, "The following vendored or generated paths were detected and are ignored:\n\n")
+		for _, i := range ignores {
+			fmt.Fprintf(&sb, "* `%s`\n", i)
+		}
+		fmt.Fprint(&sb, "\n")
+	}
+
+	if len(usages) == 0 {
+		fmt.Fprintf(&sb, "No flagged terms were found on `%s`.", base)
+	} else {
+		terms := make([]string, 0, len(usages))
+		for t := range usages {
+			terms = append(terms, t)
+		}
+		sort.Strings(terms)
+
+		fmt.Fprintf(&sb, "Existing usages of flagged terms on `%s`:\n\n| Term | Usages |\n| --- | --- |\n", base)
+		for _, t := range terms {
+			fmt.Fprintf(&sb, "| `%s` | %d |\n", t, usages[t])
+		}
+	}
+
+	if scanned < eligible {
+		fmt.Fprintf(&sb, "\n\n_Only %d of %d files were scanned._", scanned, eligible)
+	}
+	fmt.Fprint(&sb, "\n")
+
+	return sb.String()
+}
@@ -0,0 +1,84 @@
+package bot
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/zendesk/term-check/internal/githubtest"
+)
+
+func TestCountUsages(t *testing.T) {
+	s := githubtest.NewServer(t)
+	s.Respond("GET /repos/zendesk/term-check/git/blobs/readme", http.StatusOK, "The master branch\nmaster again")
+	s.Respond("GET /repos/zendesk/term-check/git/blobs/main", http.StatusOK, "package main")
+	s.Respond("GET /repos/zendesk/term-check/git/blobs/broken", http.StatusInternalServerError, "Server Error")
+	s.Respond("GET /repos/zendesk/term-check/git/blobs/data", http.StatusOK, "master\x00\x01\x02")
+
+	tree := &github.Tree{Entries: []*github.TreeEntry{
+		{Path: github.String("docs"), Type: github.String("tree")},
+		{Path: github.String("README.md"), Type: github.String("blob"), SHA: github.String("readme"), Size: github.Int(30)},
+		{Path: github.String("main.go"), Type: github.String("blob"), SHA: github.String("main"), Size: github.Int(12)},
+		{Path: github.String("broken.md"), Type: github.String("blob"), SHA: github.String("broken"), Size: github.Int(1)},
+		{Path: github.String("big.bin"), Type: github.String("blob"), SHA: github.String("big"), Size: github.Int(onboardingMaxFileSize + 1)},
+		{Path: github.String("data.dat"), Type: github.String("blob"), SHA: github.String("data"), Size: github.Int(9)},
+		{Path: github.String("logo.PNG"), Type: github.String("blob"), SHA: github.String("logo"), Size: github.Int(1)},
+		{Path: github.String("vendor/lib.go"), Type: github.String("blob"), SHA: github.String("vendored"), Size: github.Int(1)},
+	}}
+
+	b := &Bot{termPattern: regexp.MustCompile("master"), terms: []term{{source: "master", pattern: regexp.MustCompile("master")}}}
+	usages, scanned, eligible := b.countUsages(context.Background(), "zendesk", "term-check", tree, []string{"vendor/"}, s.Client)
+
+	assert.Equal(t, map[string]int{"master": 2}, usages)
+	// The file that failed to download is skipped rather than failing the onboarding, binary files aren't counted
+	assert.Equal(t, 2, scanned)
+	assert.Equal(t, 3, eligible)
+	assert.NotContains(t, s.Routes(), "GET /repos/zendesk/term-check/git/blobs/logo")
+}
+
+type detectIgnoresTestCase struct {
+	name     string
+	entries  []*github.TreeEntry
+	expected []string
+}
+
+func TestDetectIgnores(t *testing.T) {
+	tree := func(p string) *github.TreeEntry {
+		return &github.TreeEntry{Path: github.String(p), Type: github.String("tree")}
+	}
+	blob := func(p string) *github.TreeEntry {
+		return &github.TreeEntry{Path: github.String(p), Type: github.String("blob")}
+	}
+
+	cases := []detectIgnoresTestCase{
+		{
+			name:     "NothingToIgnore",
+			entries:  []*github.TreeEntry{tree("cmd"), blob("cmd/main.go"), blob("README.md")},
+			expected: nil,
+		},
+		{
+			name:     "VendoredDirectories",
+			entries:  []*github.TreeEntry{tree("vendor"), tree("web"), tree("web/node_modules"), blob("web/node_modules/x.js")},
+			expected: []string{"vendor/", "web/node_modules/"},
+		},
+		{
+			name:     "GeneratedFiles",
+			entries:  []*github.TreeEntry{blob("go.sum"), blob("api/api.pb.go"), blob("api/types.pb.go"), blob("web/app.min.js")},
+			expected: []string{"*.min.js", "*.pb.go", "go.sum"},
+		},
+		{
+			name:     "FilesNamedLikeVendoredDirectories",
+			entries:  []*github.TreeEntry{blob("dist"), blob("docs/vendor")},
+			expected: nil,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, detectIgnores(tc.entries))
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/rs/zerolog/log"
+	"github.com/zendesk/term-check/internal/config"
+	gh "github.com/zendesk/term-check/pkg/github"
+)
+
+// releaseCheckSuffix is appended to the check name for check runs on tagged commits, so they don't replace the check
+// run of the pull request that introduced the commit
+const releaseCheckSuffix = " / release"
+
+// releaseField is a piece of public facing release text, such as the release name or the tag name
+type releaseField struct {
+	name string
+	text string
+}
+
+// releaseMarker is hidden in the bot's comment on the tracking issue for a release or tag, holding the tag name, so
+// later events for the same release update the comment instead of adding another
+const releaseMarker = "<!-- term-check:release tag=%s -->"
+
+const (
+	minimizeCommentMutation = `mutation($id: ID!) {
+  minimizeComment(input: {subjectId: $id, classifier: RESOLVED}) { minimizedComment { isMinimized } }
+}`
+	unminimizeCommentMutation = `mutation($id: ID!) {
+  unminimizeComment(input: {subjectId: $id}) { unminimizedComment { isMinimized } }
+}`
+)
+
+// checkRelease checks the name, notes and tag name of a release
+func (b *Bot) checkRelease(ctx context.Context, release *github.RepositoryRelease, r *github.Repository, ghc *github.Client) {
+	title := fmt.Sprintf("[%s](%s)", release.GetName(), release.GetHTMLURL())
+	if release.GetName() == "" {
+		title = fmt.Sprintf("[%s](%s)", release.GetTagName(), release.GetHTMLURL())
+	}
+
+	fields := []releaseField{
+		{name: "Release name", text: release.GetName()},
+		{name: "Release notes", text: release.GetBody()},
+		{name: "Tag name", text: release.GetTagName()},
+	}
+	// Drafts may point at a tag that isn't created yet
+	refs := []string{release.GetTagName(), release.GetTargetCommitish()}
+	b.reportRelease(ctx, r, fmt.Sprintf("Release %s", title), release.GetTagName(), refs, fields, ghc)
+}
+
+// checkTag checks the name of a newly created tag. Tags created by publishing a release are left to the release's
+// check, which covers the tag name along with the rest of the release, so the two don't report on the same commit.
+func (b *Bot) checkTag(ctx context.Context, tag string, r *github.Repository, ghc *github.Client) {
+	if _, resp, err := ghc.Repositories.GetReleaseByTag(ctx, r.GetOwner().GetLogin(), r.GetName(), tag); err == nil {
+		log.Ctx(ctx).Debug().Str("Tag", tag).Msg("Tag belongs to a release. Discarding...")
+		return
+	} else if resp == nil || resp.StatusCode != http.StatusNotFound {
+		log.Ctx(ctx).Error().Str("Tag", tag).Err(err).Msg("Failed to get release of tag")
+		return
+	}
+
+	b.reportTag(ctx, tag, r, ghc)
+}
+
+// recheckRelease checks a release again when its check run is rerequested, finding the release through the tag the
+// check run was created for. Tags without a release have their name checked again.
+func (b *Bot) recheckRelease(ctx context.Context, cr *github.CheckRun, r *github.Repository, ghc *github.Client) {
+	tag := cr.GetExternalID()
+	if tag == "" {
+		log.Ctx(ctx).Debug().Str("SHA", cr.GetHeadSHA()).Msg("Release CheckRun has no tag. Discarding...")
+		return
+	}
+
+	release, resp, err := ghc.Repositories.GetReleaseByTag(ctx, r.GetOwner().GetLogin(), r.GetName(), tag)
+	switch {
+	case err == nil:
+		b.checkRelease(ctx, release, r, ghc)
+	case resp != nil && resp.StatusCode == http.StatusNotFound:
+		b.reportTag(ctx, tag, r, ghc)
+	default:
+		log.Ctx(ctx).Error().Str("Tag", tag).Err(err).Msg("Failed to get release of tag")
+	}
+}
+
+func (b *Bot) reportTag(ctx context.Context, tag string, r *github.Repository, ghc *github.Client) {
+	fields := []releaseField{{name: "Tag name", text: tag}}
+	b.reportRelease(ctx, r, fmt.Sprintf("Tag `%s`", tag), tag, []string{tag}, fields, ghc)
+}
+
+// reportRelease finds flagged terms in release fields and reports them in a comment on the repo's tracking issue, or
+// as a check run on the commit the first resolvable ref points at when there is no tracking issue
+func (b *Bot) reportRelease(ctx context.Context, r *github.Repository, subject, tag string, refs []string, fields []releaseField, ghc *github.Client) {
+	repo := r.GetFullName()
+
+	rc, oc, err := getConfigs(ctx, r, "", ghc)
+	if err != nil {
+		log.Ctx(ctx).Error().Str("Repo", repo).Err(err).Msg("Failed to get configuration")
+		return
+	}
+	if !rc.Releases.Enabled {
+		return
+	}
+
+	found := make(map[string][]string)
+	count := 0
+	for _, f := range fields {
+		found[f.name] = b.findInText(f.text)
+		count += len(found[f.name])
+	}
+	report := b.releaseReport(subject, fields, found)
+
+	if n := rc.Releases.Issue; n != 0 {
+		if err := b.commentOnTrackingIssue(ctx, r, n, subject, tag, report, count, ghc); err != nil {
+			log.Ctx(ctx).Error().Str("Repo", repo).Int("Issue", n).Err(err).Msg("Failed to report release findings")
+			return
+		}
+		log.Ctx(ctx).Info().Str("Repo", repo).Int("Issue", n).Msgf("Reported %d flagged term(s) in %s", count, subject)
+		return
+	}
+
+	headSHA, err := resolveCommit(ctx, r, refs, ghc)
+	if err != nil {
+		log.Ctx(ctx).Error().Str("Repo", repo).Err(err).Msg("Failed to resolve tagged commit")
+		return
+	}
+
+	opts := github.CreateCheckRunOptions{
+		Name:    b.checkName + releaseCheckSuffix,
+		HeadSHA: headSHA,
+		// The tag finds the release again when the check run is rerequested
+		ExternalID:  github.String(tag),
+		Status:      github.String("completed"),
+		Conclusion:  github.String(checkSuccessConclusion),
+		CompletedAt: &github.Timestamp{Time: time.Now()},
+		Output: &github.CheckRunOutput{
+			Title:   github.String(b.checkName + releaseCheckSuffix),
+			Summary: github.String(b.checkSuccessSummary),
+		},
+	}
+	detailsURL := r.GetHTMLURL() + "/releases"
+	if count > 0 {
+		mode := b.enforcementMode(rc, oc)
+		opts.Conclusion = github.String(modeConclusions[mode])
+		opts.Output.Summary = github.String(b.checkFailureSummary)
+		opts.Output.Text = github.String(report)
+		// Action required check runs need a page for the user to go to
+		if mode == config.ActionRequiredMode {
+			opts.DetailsURL = github.String(detailsURL)
+		}
+	}
+
+	if err := b.reportOnCommit(ctx, r, opts, detailsURL, ghc); err != nil {
+		log.Ctx(ctx).Error().Str("SHA", headSHA).Err(err).Msg("Failed to report release findings")
+		return
+	}
+	log.Ctx(ctx).Info().Str("SHA", headSHA).Msgf("Reported %d flagged term(s) in %s", count, subject)
+}
+
+// commentOnTrackingIssue keeps a single comment per release on the tracking issue, created once terms are found and
+// updated on later events. The comment is collapsed as resolved once the terms are gone, and expanded again if they
+// come back.
+func (b *Bot) commentOnTrackingIssue(ctx context.Context, r *github.Repository, number int, subject, tag, report string, count int, ghc *github.Client) error {
+	marker := fmt.Sprintf(releaseMarker, tag)
+
+	existing, err := b.findBotComment(ctx, r, number, marker, ghc)
+	if err != nil {
+		return err
+	}
+	if existing == nil && count == 0 {
+		return nil
+	}
+
+	body := fmt.Sprintf("%s\n%s\n", report, marker)
+	if count == 0 {
+		body = fmt.Sprintf("%s no longer uses flagged terms.\n%s\n", subject, marker)
+	}
+	if err := writeBotComment(ctx, r, number, existing, body, ghc); err != nil {
+		return err
+	}
+
+	// Only comments that were there before can have been collapsed
+	if existing == nil {
+		return nil
+	}
+	mutation := minimizeCommentMutation
+	if count > 0 {
+		mutation = unminimizeCommentMutation
+	}
+	var res interface{}
+	if err := gh.GraphQL(ctx, ghc, mutation, map[string]interface{}{"id": existing.GetNodeID()}, &res); err != nil {
+		return fmt.Errorf("Failed to update comment on #%d: %s", number, err)
+	}
+	return nil
+}
+
+// resolveCommit returns the SHA of the commit the first resolvable of the passed in refs points at
+func resolveCommit(ctx context.Context, r *github.Repository, refs []string, ghc *github.Client) (string, error) {
+	var err error
+	for _, ref := range refs {
+		if ref == "" {
+			continue
+		}
+
+		var sha string
+		if sha, _, err = ghc.Repositories.GetCommitSHA1(ctx, r.GetOwner().GetLogin(), r.GetName(), ref, ""); err == nil {
+			return sha, nil
+		}
+	}
+	return "", fmt.Errorf("Failed to resolve %s: %s", strings.Join(refs, ", "), err)
+}
+
+// releaseReport renders the flagged terms found in each release field
+func (b *Bot) releaseReport(subject string, fields []releaseField, found map[string][]string) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "### %s\n\n%s\n\n%s\n\n", b.checkName, b.checkFailureSummary, subject)
+	fmt.Fprint(&sb, "| Field | Term | Suggestion |\n| --- | --- | --- |\n")
+	for _, f := range fields {
+		for _, m := range found[f.name] {
+			suggestion := ""
+			if t, ok := b.lookupTerm(m); ok {
+				suggestion = strings.Join(t.alternatives, ", ")
+			}
+			fmt.Fprintf(&sb, "| %s | `%s` | %s |\n", f.name, m, suggestion)
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,174 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/zendesk/term-check/internal/githubtest"
+)
+
+// newReleaseBot returns a bot flagging master, as the release checks use it
+func newReleaseBot() *Bot {
+	return &Bot{
+		appID:               1,
+		appSlug:             "term-check",
+		checkName:           "term-check",
+		checkSuccessSummary: "All good.",
+		checkFailureSummary: "Flagged terms found.",
+		mode:                "advisory",
+		termPattern:         regexp.MustCompile("(?i)master"),
+		terms:               []term{{source: "(?i)master", pattern: regexp.MustCompile("(?i)master"), alternatives: []string{"main"}}},
+	}
+}
+
+type commentOnTrackingIssueTestCase struct {
+	name             string
+	comments         string
+	count            int
+	expectedRequests []string
+	expectedBody     string
+	expectedMutation string
+}
+
+func TestCommentOnTrackingIssue(t *testing.T) {
+	marker := fmt.Sprintf(releaseMarker, "v1")
+	comments := `[
+		{"id": 4, "node_id": "IC_4", "user": {"login": "term-check[bot]", "type": "Bot"}, "body": "<!-- term-check:release tag=v2 -->"},
+		{"id": 5, "node_id": "IC_5", "user": {"login": "term-check[bot]", "type": "Bot"}, "body": "` + marker + `"}
+	]`
+
+	cases := []commentOnTrackingIssueTestCase{
+		{
+			name:     "CreatesComment",
+			comments: `[]`,
+			count:    1,
+			expectedRequests: []string{
+				"GET /repos/zendesk/term-check/issues/43/comments",
+				"POST /repos/zendesk/term-check/issues/43/comments",
+			},
+			expectedBody: "report",
+		},
+		{
+			name:     "UpdatesCommentOfSameRelease",
+			comments: comments,
+			count:    1,
+			expectedRequests: []string{
+				"GET /repos/zendesk/term-check/issues/43/comments",
+				"PATCH /repos/zendesk/term-check/issues/comments/5",
+				"POST /graphql",
+			},
+			expectedBody:     "report",
+			expectedMutation: "unminimizeComment",
+		},
+		{
+			name:     "CollapsesCommentOnceResolved",
+			comments: comments,
+			count:    0,
+			expectedRequests: []string{
+				"GET /repos/zendesk/term-check/issues/43/comments",
+				"PATCH /repos/zendesk/term-check/issues/comments/5",
+				"POST /graphql",
+			},
+			expectedBody:     "Release v1 no longer uses flagged terms.",
+			expectedMutation: "minimizeComment(",
+		},
+		{
+			name:     "NothingToReport",
+			comments: `[]`,
+			count:    0,
+			expectedRequests: []string{
+				"GET /repos/zendesk/term-check/issues/43/comments",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := githubtest.NewServer(t)
+			s.Respond("GET /repos/zendesk/term-check/issues/43/comments", http.StatusOK, tc.comments)
+			s.Respond("POST /repos/zendesk/term-check/issues/43/comments", http.StatusCreated, `{}`)
+			s.Respond("PATCH /repos/zendesk/term-check/issues/comments/5", http.StatusOK, `{}`)
+			s.Respond("POST /graphql", http.StatusOK, `{"data": {}}`)
+
+			err := newReleaseBot().commentOnTrackingIssue(context.Background(), testRepo, 43, "Release v1", "v1", "report", tc.count, s.Client)
+
+			assert.NoError(t, err)
+			requests := s.Requests()
+			assert.Equal(t, tc.expectedRequests, s.Routes())
+			if len(requests) > 1 {
+				body := requests[1].Body["body"].(string)
+				assert.Contains(t, body, tc.expectedBody)
+				assert.Contains(t, body, marker)
+			}
+			if tc.expectedMutation != "" {
+				mutation := s.Body("POST /graphql")
+				assert.Contains(t, mutation["query"], tc.expectedMutation)
+				assert.Equal(t, map[string]interface{}{"id": "IC_5"}, mutation["variables"])
+			}
+		})
+	}
+}
+
+type recheckReleaseTestCase struct {
+	name               string
+	externalID         string
+	release            string
+	expectedConclusion string
+	expectedText       string
+}
+
+func TestRecheckRelease(t *testing.T) {
+	cases := []recheckReleaseTestCase{
+		{
+			name:               "Release",
+			externalID:         "v1",
+			release:            `{"tag_name": "v1", "name": "Drop master", "html_url": "https://github.com/zendesk/term-check/releases/v1"}`,
+			expectedConclusion: "action_required",
+			expectedText:       "| Release name | `master` | main |",
+		},
+		{
+			name:               "TagWithoutRelease",
+			externalID:         "v1",
+			expectedConclusion: "success",
+		},
+		{
+			name:       "NoTag",
+			externalID: "",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := githubtest.NewServer(t)
+			s.Respond("GET /repos/zendesk/term-check/contents/.github/term-check.yaml", http.StatusOK, githubtest.Contents("mode: action_required\nreleases:\n  enabled: true\n"))
+			if tc.release != "" {
+				s.Respond("GET /repos/zendesk/term-check/releases/tags/v1", http.StatusOK, tc.release)
+			}
+			s.Respond("GET /repos/zendesk/term-check/commits/v1", http.StatusOK, "abc")
+			s.Respond("GET /repos/zendesk/term-check/commits/abc/check-runs", http.StatusOK, `{"total_count": 1, "check_runs": [{"id": 1, "app": {"id": 1}}]}`)
+			s.Respond("PATCH /repos/zendesk/term-check/check-runs/1", http.StatusOK, `{"id": 1}`)
+
+			cr := &github.CheckRun{ID: github.Int64(1), HeadSHA: github.String("abc"), ExternalID: github.String(tc.externalID)}
+			newReleaseBot().recheckRelease(context.Background(), cr, testRepo, s.Client)
+
+			update := s.Body("PATCH /repos/zendesk/term-check/check-runs/1")
+			if tc.expectedConclusion == "" {
+				assert.Nil(t, update)
+				return
+			}
+			if assert.NotNil(t, update) {
+				assert.Equal(t, tc.expectedConclusion, update["conclusion"])
+				// The check run keeps the tag, so it can be rerequested again
+				assert.Equal(t, "v1", update["external_id"])
+				output, _ := json.Marshal(update["output"])
+				assert.Contains(t, string(output), tc.expectedText)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/zendesk/term-check/internal/config"
+	"github.com/zendesk/term-check/pkg/lib"
+)
+
+// reviewCommentMarker is hidden in the body of every suggested change so the bot can recognize its own comments
+const reviewCommentMarker = "<!-- term-check:suggestion -->"
+
+// suggestChangesFor returns whether suggested changes should be posted for a repo, preferring the repo's own setting
+func (b *Bot) suggestChangesFor(rc *config.RepoConfig) bool {
+	if rc.SuggestChanges != nil {
+		return *rc.SuggestChanges
+	}
+	return b.suggestChanges
+}
+
+// suggest returns the content of a finding with every flagged term that has alternatives replaced by its first
+// alternative, keeping the casing of the original, along with whether anything was replaced. Only the ranges the terms
+// were found in are replaced, so the parts of the line its extractor skips, e.x. markup attributes, are left as is.
+func (b *Bot) suggest(f finding) (string, bool) {
+	var sb strings.Builder
+	replaced, last := false, 0
+	for _, span := range f.spans {
+		if span[0] < last {
+			continue
+		}
+		m := f.content[span[0]:span[1]]
+		alt := b.replaceTerm(m)
+
+		sb.WriteString(f.content[last:span[0]])
+		sb.WriteString(alt)
+		last = span[1]
+		replaced = replaced || alt != m
+	}
+	sb.WriteString(f.content[last:])
+	return sb.String(), replaced
+}
+
+// replaceTerm returns a match of the flagged terms replaced by the first alternative of the first term with
+// alternatives matching it, or the match as is when there is none
+func (b *Bot) replaceTerm(m string) string {
+	for _, t := range b.terms {
+		if len(t.alternatives) == 0 || !t.pattern.MatchString(m) {
+			continue
+		}
+		return t.pattern.ReplaceAllStringFunc(m, func(s string) string {
+			return lib.MatchCase(s, t.alternatives[0])
+		})
+	}
+	return m
+}
+
+// createReview posts a pull request review with a suggested change for every finding that has an alternative, and a
+// plain comment for every other finding when everyFinding is set. Comments already posted by a previous run for the
+// same line are not repeated.
+func (b *Bot) createReview(ctx context.Context, pr *github.PullRequest, r *github.Repository, ghc *github.Client, findings []finding, everyFinding bool) error {
+	headSHA := pr.GetHead().GetSHA()
+
+	existing, err := b.existingSuggestions(ctx, pr, r, ghc)
+	if err != nil {
+		return err
+	}
+
+	var comments []*github.DraftReviewComment
+	for _, f := range findings {
+		var body string
+		if s, ok := b.suggest(f); ok {
+			body = fmt.Sprintf("%s\n\n```suggestion\n%s\n```\n%s", b.annotationMessage(f.terms), s, reviewCommentMarker)
+		} else if everyFinding {
+			body = fmt.Sprintf("%s\n%s", b.annotationMessage(f.terms), reviewCommentMarker)
+		} else {
+			continue
+		}
+
+		if lib.Contains(existing, suggestionKey(f.path, f.line, body)) {
+			continue
+		}
+
+		comments = append(comments, &github.DraftReviewComment{
+			Path: github.String(f.path),
+			Line: github.Int(f.line),
+			Side: github.String("RIGHT"),
+			Body: github.String(body),
+		})
+	}
+
+	if len(comments) == 0 {
+		return nil
+	}
+
+	_, resp, err := ghc.PullRequests.CreateReview(ctx, r.GetOwner().GetLogin(), r.GetName(), pr.GetNumber(), &github.PullRequestReviewRequest{
+		CommitID: github.String(headSHA),
+		Event:    github.String("COMMENT"),
+		Comments: comments,
+	})
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Failed to create review for %s: %s", headSHA, err)
+	}
+
+	return nil
+}
+
+// existingSuggestions returns the keys of all suggested changes the bot has already posted on the pull request
+func (b *Bot) existingSuggestions(ctx context.Context, pr *github.PullRequest, r *github.Repository, ghc *github.Client) (map[string]struct{}, error) {
+	existing := make(map[string]struct{})
+
+	opts := &github.PullRequestListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		comments, resp, err := ghc.PullRequests.ListComments(ctx, r.GetOwner().GetLogin(), r.GetName(), pr.GetNumber(), opts)
+		if err != nil {
+			return existing, fmt.Errorf("Failed to list review comments for %s: %s", pr.GetHead().GetSHA(), err)
+		}
+
+		for _, c := range comments {
+			existing[suggestionKey(c.GetPath(), c.GetLine(), c.GetBody())] = struct{}{}
+		}
+
+		if resp.NextPage == 0 {
+			return existing, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+func suggestionKey(path string, line int, body string) string {
+	return fmt.Sprintf("%s:%d:%s", path, line, body)
+}
@@ -0,0 +1,67 @@
+package bot
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zendesk/term-check/pkg/extract"
+)
+
+type suggestTestCase struct {
+	name     string
+	path     string
+	content  string
+	expected string
+}
+
+func TestSuggest(t *testing.T) {
+	b := &Bot{
+		termPattern: regexp.MustCompile("(?i)master|slave"),
+		terms: []term{
+			{source: "(?i)master", pattern: regexp.MustCompile("(?i)master"), alternatives: []string{"main"}},
+			{source: "slave", pattern: regexp.MustCompile("slave")},
+		},
+	}
+
+	cases := []suggestTestCase{
+		{
+			name:     "Text",
+			path:     "README.md",
+			content:  "Master and slave, then master again",
+			expected: "Main and slave, then main again",
+		},
+		{
+			name:     "MarkupKeepsAttributes",
+			path:     "logo.svg",
+			content:  `<text id="master" class="Master">Master &amp; master</text>`,
+			expected: `<text id="master" class="Master">Main &amp; main</text>`,
+		},
+		{
+			name:     "NotebookKeepsMetadata",
+			path:     "analysis.ipynb",
+			content:  `"metadata": {"master": "master"}, "source": ["Use master\n"]`,
+			expected: `"metadata": {"master": "master"}, "source": ["Use main\n"]`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			findings := b.matchLines(tc.path, []extract.Line{{Number: 1, Content: tc.content}})
+			if !assert.Len(t, findings, 1) {
+				return
+			}
+
+			s, ok := b.suggest(findings[0])
+			assert.True(t, ok)
+			assert.Equal(t, tc.expected, s)
+		})
+	}
+
+	// Lines where only terms without alternatives are found have nothing to suggest
+	findings := b.matchLines("README.md", []extract.Line{{Number: 1, Content: "a slave process"}})
+	if assert.Len(t, findings, 1) {
+		_, ok := b.suggest(findings[0])
+		assert.False(t, ok)
+	}
+}
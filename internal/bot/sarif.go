@@ -0,0 +1,73 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/rs/zerolog/log"
+	"github.com/zendesk/term-check/internal/config"
+	gh "github.com/zendesk/term-check/pkg/github"
+	"github.com/zendesk/term-check/pkg/sarif"
+)
+
+// sarifInformationURI is linked from code scanning alerts as the tool's home page
+const sarifInformationURI = "https://github.com/zendesk/term-check"
+
+// uploadSARIFFor returns whether findings should be uploaded to code scanning for a repo, preferring the repo's own
+// setting
+func (b *Bot) uploadSARIFFor(rc *config.RepoConfig) bool {
+	if rc.UploadSARIF != nil {
+		return *rc.UploadSARIF
+	}
+	return b.uploadSARIF
+}
+
+// sarifLog converts findings into a SARIF log, with a result for every flagged term found on a line
+func (b *Bot) sarifLog(findings []finding) *sarif.Log {
+	terms := make([]sarif.Term, 0, len(b.terms))
+	for _, t := range b.terms {
+		terms = append(terms, sarif.Term{Term: t.source, Alternatives: t.alternatives, Category: t.category})
+	}
+
+	var results []sarif.Finding
+	for _, f := range findings {
+		for _, m := range f.terms {
+			t, ok := b.lookupTerm(m)
+			if !ok {
+				continue
+			}
+			results = append(results, sarif.Finding{
+				Path:    f.path,
+				Line:    f.line,
+				Term:    t.source,
+				Match:   m,
+				Message: strings.TrimSpace(b.annotationMessage([]string{m})),
+			})
+		}
+	}
+
+	return sarif.New(b.checkName, sarifInformationURI, terms, results)
+}
+
+// uploadFindings uploads findings to code scanning as the results for the head of a pull request. An upload without
+// findings closes the alerts of earlier uploads.
+func (b *Bot) uploadFindings(ctx context.Context, pr *github.PullRequest, r *github.Repository, ghc *github.Client, findings []finding) error {
+	headSHA := pr.GetHead().GetSHA()
+
+	var buf bytes.Buffer
+	if err := b.sarifLog(findings).Write(&buf); err != nil {
+		return fmt.Errorf("Failed to write SARIF for %s: %s", headSHA, err)
+	}
+
+	ref := fmt.Sprintf("refs/pull/%d/head", pr.GetNumber())
+	id, err := gh.UploadSARIF(ctx, ghc, r.GetOwner().GetLogin(), r.GetName(), headSHA, ref, buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	log.Ctx(ctx).Info().Str("SHA", headSHA).Msgf("Uploaded SARIF %s", id)
+	return nil
+}
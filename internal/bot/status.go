@@ -0,0 +1,147 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/rs/zerolog/log"
+)
+
+// maxStatusDescription is the longest description GitHub accepts on a commit status
+const maxStatusDescription = 140
+
+// statusStates maps check run conclusions to the closest commit status state. Statuses have no neutral state, so
+// conclusions that don't block merging are reported as success.
+var statusStates = map[string]string{
+	"success":         "success",
+	"neutral":         "success",
+	"action_required": "failure",
+	"failure":         "failure",
+	"cancelled":       "error",
+}
+
+// tokenMode returns whether the bot authenticates with a personal access token rather than as an application. Tokens
+// cannot use the Checks API, so results are reported as commit statuses and review comments instead.
+func (b *Bot) tokenMode() bool {
+	return b.withToken
+}
+
+// createStatus checks a pull request in the same way as createCheckRun, reporting the result as a commit status
+func (b *Bot) createStatus(ctx context.Context, pr *github.PullRequest, r *github.Repository, ghc *github.Client) {
+	headSHA := pr.GetHead().GetSHA()
+	targetURL := fmt.Sprintf("%s/pull/%d", r.GetHTMLURL(), pr.GetNumber())
+
+	log.Ctx(ctx).Info().Str("SHA", headSHA).Msg("Creating Status...")
+
+	if err := b.setStatus(ctx, r, headSHA, b.checkName, "pending", fmt.Sprintf("%s is running", b.checkName), targetURL, ghc); err != nil {
+		log.Ctx(ctx).Error().Str("SHA", headSHA).Err(err).Msg("Failed to POST Status")
+		return
+	}
+
+	rc, oc, err := getConfigs(ctx, r, headSHA, ghc)
+	if err != nil {
+		b.failStatus(ctx, r, headSHA, targetURL, ghc, err)
+		return
+	}
+
+	sc, err := b.scanPullRequest(ctx, pr, r, ghc, rc)
+	if err != nil {
+		b.failStatus(ctx, r, headSHA, targetURL, ghc, err)
+		return
+	}
+
+	ex, err := b.getExemptions(ctx, pr, r, ghc)
+	if err != nil {
+		b.failStatus(ctx, r, headSHA, targetURL, ghc, err)
+		return
+	}
+	findings := b.filter(sc.findings, ex)
+
+	cro := b.checkRunResult(findings, rc, oc, ex, targetURL)
+	description := cro.Output.GetSummary()
+	if len(findings) > 0 && !ex.pr {
+		description = fmt.Sprintf("%d line(s) with flagged terms, see the review comments", len(findings))
+	}
+	if err := b.setStatus(ctx, r, headSHA, b.checkName, statusStates[cro.GetConclusion()], description, targetURL, ghc); err != nil {
+		log.Ctx(ctx).Error().Str("SHA", headSHA).Err(err).Msg("Failed to complete Status")
+	} else {
+		log.Ctx(ctx).Info().Str("SHA", headSHA).Msg("Successfully created Status")
+	}
+
+	b.reportOnPullRequest(ctx, pr, r, ghc, rc, findings, sc.removed, nil, ex)
+}
+
+// failStatus reports a commit status for a check that could not finish
+func (b *Bot) failStatus(ctx context.Context, r *github.Repository, headSHA, targetURL string, ghc *github.Client, cause error) {
+	log.Ctx(ctx).Error().Str("SHA", headSHA).Err(cause).Msg("Failed to check for flagged terms")
+
+	state, description := "error", fmt.Sprintf("%s could not check this commit.", b.checkName)
+	if ctx.Err() != nil {
+		description = fmt.Sprintf("%s was cancelled before it could finish.", b.checkName)
+		// The original context is done, but the status still has to be completed
+		ctx = context.Background()
+	}
+
+	if err := b.setStatus(ctx, r, headSHA, b.checkName, state, description, targetURL, ghc); err != nil {
+		log.Ctx(ctx).Error().Str("SHA", headSHA).Err(err).Msg("Failed to complete Status")
+	}
+}
+
+// setStatus creates a commit status, shortening the description to what GitHub accepts
+func (b *Bot) setStatus(ctx context.Context, r *github.Repository, headSHA, name, state, description, targetURL string, ghc *github.Client) error {
+	description = strings.SplitN(strings.TrimSpace(description), "\n", 2)[0]
+	if len(description) > maxStatusDescription {
+		description = description[:maxStatusDescription-3] + "..."
+	}
+
+	_, resp, err := ghc.Repositories.CreateStatus(ctx, r.GetOwner().GetLogin(), r.GetName(), headSHA, &github.RepoStatus{
+		State:       github.String(state),
+		Description: github.String(description),
+		Context:     github.String(name),
+		TargetURL:   github.String(targetURL),
+	})
+	if err != nil || resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("Failed to create Status for %s: %s", headSHA, err)
+	}
+	return nil
+}
+
+// reportOnCommit reports a completed check run, or the equivalent commit status in token mode. The bot's existing
+// check run with the same name on the commit is updated, so repeated reports don't pile up check runs.
+func (b *Bot) reportOnCommit(ctx context.Context, r *github.Repository, opts github.CreateCheckRunOptions, targetURL string, ghc *github.Client) error {
+	if b.tokenMode() {
+		return b.setStatus(ctx, r, opts.HeadSHA, opts.Name, statusStates[opts.GetConclusion()], opts.Output.GetSummary(), targetURL, ghc)
+	}
+	owner, name := r.GetOwner().GetLogin(), r.GetName()
+
+	existing, err := b.findCheckRun(ctx, r, opts.HeadSHA, opts.Name, ghc)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		_, resp, err := ghc.Checks.UpdateCheckRun(ctx, owner, name, existing.GetID(), github.UpdateCheckRunOptions{
+			Name:        opts.Name,
+			DetailsURL:  opts.DetailsURL,
+			ExternalID:  opts.ExternalID,
+			Status:      opts.Status,
+			Conclusion:  opts.Conclusion,
+			CompletedAt: opts.CompletedAt,
+			Output:      opts.Output,
+			Actions:     opts.Actions,
+		})
+		if err != nil || resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("Failed to update CheckRun %d for %s: %s", existing.GetID(), opts.HeadSHA, err)
+		}
+		return nil
+	}
+
+	_, resp, err := ghc.Checks.CreateCheckRun(ctx, owner, name, opts)
+	if err != nil || resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("Failed to create CheckRun for %s: %s", opts.HeadSHA, err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package bot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/stretchr/testify/assert"
+)
+
+type reportOnCommitTestCase struct {
+	name             string
+	checkRuns        string
+	expectedRequests []string
+}
+
+func TestReportOnCommit(t *testing.T) {
+	cases := []reportOnCommitTestCase{
+		{
+			name:      "CreatesCheckRun",
+			checkRuns: `{"total_count": 0, "check_runs": []}`,
+			expectedRequests: []string{
+				"GET /repos/zendesk/term-check/commits/abc/check-runs",
+				"POST /repos/zendesk/term-check/check-runs",
+			},
+		},
+		{
+			name:      "UpdatesExistingCheckRun",
+			checkRuns: `{"total_count": 1, "check_runs": [{"id": 1, "app": {"id": 1}}]}`,
+			expectedRequests: []string{
+				"GET /repos/zendesk/term-check/commits/abc/check-runs",
+				"PATCH /repos/zendesk/term-check/check-runs/1",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newFakeChecks(t, tc.checkRuns, "[]")
+
+			b := &Bot{appID: 1, checkName: "term-check"}
+			err := b.reportOnCommit(context.Background(), testRepo, github.CreateCheckRunOptions{
+				Name:       "term-check / release",
+				HeadSHA:    "abc",
+				Status:     github.String("completed"),
+				Conclusion: github.String("success"),
+				Output:     &github.CheckRunOutput{Title: github.String("term-check / release"), Summary: github.String("All good")},
+			}, "https://github.com/zendesk/term-check/releases", s.Client)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedRequests, s.Routes())
+			assert.Equal(t, "term-check / release", s.Requests()[1].Body["name"])
+		})
+	}
+}
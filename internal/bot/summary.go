@@ -0,0 +1,101 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/zendesk/term-check/internal/config"
+)
+
+// summaryMarker is hidden in the summary comment so the bot can find and edit it on later runs
+const summaryMarker = "<!-- term-check:summary -->"
+
+// maxCommentBody is the longest body GitHub accepts for a comment
+const maxCommentBody = 65536
+
+// summaryCommentFor returns whether a summary comment should be kept on pull requests for a repo, preferring the
+// repo's own setting
+func (b *Bot) summaryCommentFor(rc *config.RepoConfig) bool {
+	if rc.SummaryComment != nil {
+		return *rc.SummaryComment
+	}
+	return b.summaryComment
+}
+
+// updateSummaryComment creates or edits the bot's summary comment on a pull request to list the findings of the
+// latest run. No comment is created while a pull request has never had findings.
+func (b *Bot) updateSummaryComment(ctx context.Context, pr *github.PullRequest, r *github.Repository, ghc *github.Client, findings []finding, removed int, cr *github.CheckRun, ex *exemptions) error {
+	existing, err := b.findBotComment(ctx, r, pr.GetNumber(), summaryMarker, ghc)
+	if err != nil {
+		return err
+	}
+	if existing == nil && len(findings) == 0 {
+		return nil
+	}
+
+	body := b.summaryBody(r, pr.GetHead().GetSHA(), findings, removed, cr, ex)
+	return writeBotComment(ctx, r, pr.GetNumber(), existing, body, ghc)
+}
+
+// summaryBody renders the Markdown body of the summary comment
+func (b *Bot) summaryBody(r *github.Repository, headSHA string, findings []finding, removed int, cr *github.CheckRun, ex *exemptions) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "### %s\n\n", b.checkName)
+
+	if len(findings) == 0 {
+		fmt.Fprintf(&sb, "%s All clear, no flagged terms are added by this pull request.\n", b.checkSuccessSummary)
+		if removed > 0 {
+			fmt.Fprintf(&sb, "\nThanks for removing %d use(s) of flagged terms!\n", removed)
+		}
+		fmt.Fprintf(&sb, "\n%s\n", summaryMarker)
+		return sb.String()
+	}
+
+	added := 0
+	for _, f := range findings {
+		added += len(f.terms)
+	}
+
+	fmt.Fprintf(&sb, "%s\n\n", b.checkFailureSummary)
+	if ex.pr {
+		fmt.Fprintf(&sb, "Ignored for this pull request by @%s.\n\n", ex.prBy)
+	}
+	fmt.Fprintf(&sb, "Flagged terms added: **%d**, removed: **%d**\n\n", added, removed)
+
+	fmt.Fprint(&sb, "| File | Line | Term | Suggestion |\n| --- | --- | --- | --- |\n")
+	truncated := false
+rows:
+	for _, f := range findings {
+		url := blobURL(r.GetHTMLURL(), headSHA, f.path, f.line)
+		for _, m := range f.terms {
+			suggestion := ""
+			if t, ok := b.lookupTerm(m); ok && len(t.alternatives) > 0 {
+				suggestion = tableCell(strings.Join(t.alternatives, ", "))
+			}
+			row := fmt.Sprintf("| [%s](%s) | %d | %s | %s |\n", codeSpan(f.path), url, f.line, codeSpan(m), suggestion)
+
+			// Leaves room for the footer
+			if sb.Len()+len(row) > maxCommentBody-1000 {
+				truncated = true
+				break rows
+			}
+			sb.WriteString(row)
+		}
+	}
+
+	u := cr.GetHTMLURL()
+	switch {
+	case truncated && u != "":
+		fmt.Fprintf(&sb, "\nTruncated, see the [annotations](%s) on the check run for all findings.\n", u)
+	case truncated:
+		fmt.Fprint(&sb, "\nTruncated, see the annotations on the check run for all findings.\n")
+	case u != "":
+		fmt.Fprintf(&sb, "\nSee the [annotations](%s) on the check run for details.\n", u)
+	}
+	fmt.Fprintf(&sb, "\n%s\n", summaryMarker)
+
+	return sb.String()
+}
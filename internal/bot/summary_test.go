@@ -0,0 +1,96 @@
+package bot
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/stretchr/testify/assert"
+)
+
+type summaryBodyTestCase struct {
+	name        string
+	findings    []finding
+	removed     int
+	ex          *exemptions
+	contains    []string
+	notContains []string
+}
+
+func TestSummaryBody(t *testing.T) {
+	var many []finding
+	for i := 0; i < 2000; i++ {
+		many = append(many, finding{path: "docs/a-rather-long-path/to/a/file.md", line: i + 1, terms: []string{"master"}})
+	}
+
+	cases := []summaryBodyTestCase{
+		{
+			name:        "NoFindings",
+			ex:          &exemptions{},
+			contains:    []string{"All clear", summaryMarker},
+			notContains: []string{"Thanks for removing"},
+		},
+		{
+			name:     "NoFindingsWithRemovals",
+			removed:  2,
+			ex:       &exemptions{},
+			contains: []string{"All clear", "Thanks for removing 2 use(s)"},
+		},
+		{
+			name:     "Findings",
+			findings: []finding{{path: "README.md", line: 3, terms: []string{"master"}}},
+			removed:  1,
+			ex:       &exemptions{},
+			contains: []string{
+				"Flagged terms added: **1**, removed: **1**",
+				"| [`README.md`](https://github.com/zendesk/term-check/blob/abc/README.md#L3) | 3 | `master` | main |",
+				"See the [annotations](https://github.com/zendesk/term-check/runs/1)",
+				summaryMarker,
+			},
+			notContains: []string{"Ignored for this pull request", "Truncated"},
+		},
+		{
+			name:     "EscapedFindings",
+			findings: []finding{{path: "docs/a|b `c`.md", line: 3, terms: []string{"master"}}},
+			ex:       &exemptions{},
+			contains: []string{
+				"| [``docs/a\\|b `c`.md``](https://github.com/zendesk/term-check/blob/abc/docs/a%7Cb%20%60c%60.md#L3) | 3 | `master` | main |",
+			},
+		},
+		{
+			name:     "IgnoredPullRequest",
+			findings: []finding{{path: "README.md", line: 3, terms: []string{"master"}}},
+			ex:       &exemptions{pr: true, prBy: "octocat"},
+			contains: []string{"Ignored for this pull request by @octocat."},
+		},
+		{
+			name:     "Truncated",
+			findings: many,
+			ex:       &exemptions{},
+			contains: []string{"Truncated, see the [annotations](https://github.com/zendesk/term-check/runs/1)", summaryMarker},
+		},
+	}
+
+	b := &Bot{
+		checkName:           "term-check",
+		checkSuccessSummary: "Success!",
+		checkFailureSummary: "Flagged terms found.",
+		terms:               []term{{source: "master", pattern: regexp.MustCompile("master"), alternatives: []string{"main"}}},
+	}
+	r := &github.Repository{HTMLURL: github.String("https://github.com/zendesk/term-check")}
+	cr := &github.CheckRun{HTMLURL: github.String("https://github.com/zendesk/term-check/runs/1")}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			body := b.summaryBody(r, "abc", tc.findings, tc.removed, cr, tc.ex)
+
+			for _, s := range tc.contains {
+				assert.Contains(t, body, s)
+			}
+			for _, s := range tc.notContains {
+				assert.NotContains(t, body, s)
+			}
+			assert.LessOrEqual(t, len(body), maxCommentBody)
+		})
+	}
+}
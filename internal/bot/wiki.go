@@ -0,0 +1,281 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/rs/zerolog/log"
+	"github.com/zendesk/term-check/internal/config"
+	"github.com/zendesk/term-check/pkg/extract"
+)
+
+const wikiIssueTitle = "Flagged terms in wiki page %s"
+
+// wikiMarker is hidden in the bot's report on a wiki page, holding the page's name, so later edits of the page update
+// the report
+const wikiMarker = "<!-- term-check:wiki page=%s -->"
+
+// gitVersionPattern matches the output of git --version, e.x. "git version 2.39.3 (Apple Git-145)"
+var gitVersionPattern = regexp.MustCompile(`^git version (\d+)\.(\d+)`)
+
+// wikiPage is a wiki page revision along with the findings in it
+type wikiPage struct {
+	page     *github.Page
+	findings []finding
+}
+
+// checkWiki checks the revisions of the wiki pages changed in a gollum event, reading them from a local clone of the
+// wiki repository as the API does not serve wiki contents. Each page has a single report, kept as a comment on the
+// issue configured by the repo, or as an issue of its own when none is configured. Reports are only created once
+// terms are found, and updated on later edits.
+func (b *Bot) checkWiki(ctx context.Context, pages []*github.Page, r *github.Repository, installationID int, ghc *github.Client) {
+	repo := r.GetFullName()
+
+	rc, err := config.GetRepoConfig(ctx, r, "", ghc)
+	if err != nil {
+		log.Ctx(ctx).Error().Str("Repo", repo).Err(err).Msg("Failed to get repository configuration")
+		return
+	}
+	if !rc.Wiki.Enabled {
+		return
+	}
+	if b.wikiGitErr != nil {
+		log.Ctx(ctx).Error().Str("Repo", repo).Err(b.wikiGitErr).Msg("Failed to check wiki")
+		return
+	}
+
+	token, err := b.client.InstallationToken(installationID)
+	if err != nil {
+		log.Ctx(ctx).Error().Str("Repo", repo).Err(err).Msg("Failed to get installation token")
+		return
+	}
+
+	// Clones are shared between deliveries for the same repo, so git commands are not run concurrently on a clone.
+	// Wikis of other repos are checked meanwhile.
+	dir := b.wikiDir(r)
+	mu := b.wikiLock(dir)
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := syncWiki(ctx, r, dir, token); err != nil {
+		log.Ctx(ctx).Error().Str("Repo", repo).Err(err).Msg("Failed to sync wiki")
+		return
+	}
+
+	for _, p := range pages {
+		file, content, err := readWikiPage(ctx, dir, p)
+		if err != nil {
+			log.Ctx(ctx).Error().Str("Repo", repo).Str("Page", p.GetPageName()).Err(err).Msg("Failed to read wiki page")
+			continue
+		}
+
+		var lines []extract.Line
+		for i, l := range strings.Split(content, "\n") {
+			lines = append(lines, extract.Line{Number: i + 1, Content: l})
+		}
+		wp := wikiPage{page: p, findings: b.matchLines(file, lines)}
+
+		if n := rc.Wiki.Issue; n != 0 {
+			err = b.commentOnWikiPage(ctx, wp, r, n, ghc)
+		} else {
+			err = b.openWikiPageIssue(ctx, wp, r, ghc)
+		}
+		if err != nil {
+			log.Ctx(ctx).Error().Str("Repo", repo).Str("Page", p.GetPageName()).Err(err).Msg("Failed to report wiki findings")
+			continue
+		}
+	}
+}
+
+// commentOnWikiPage keeps the report on a wiki page as the bot's comment on an issue
+func (b *Bot) commentOnWikiPage(ctx context.Context, wp wikiPage, r *github.Repository, number int, ghc *github.Client) error {
+	existing, err := b.findBotComment(ctx, r, number, fmt.Sprintf(wikiMarker, wp.page.GetPageName()), ghc)
+	if err != nil {
+		return err
+	}
+	if existing == nil && len(wp.findings) == 0 {
+		return nil
+	}
+
+	if err := writeBotComment(ctx, r, number, existing, b.wikiReport(wp), ghc); err != nil {
+		return err
+	}
+	log.Ctx(ctx).Info().Str("Repo", r.GetFullName()).Str("Page", wp.page.GetPageName()).Msgf("Reported %d flagged line(s) on #%d", len(wp.findings), number)
+	return nil
+}
+
+// openWikiPageIssue keeps the report on a wiki page as an issue opened by the bot. The issue is closed once the page
+// no longer uses flagged terms.
+func (b *Bot) openWikiPageIssue(ctx context.Context, wp wikiPage, r *github.Repository, ghc *github.Client) error {
+	owner, name := r.GetOwner().GetLogin(), r.GetName()
+
+	existing, err := b.findBotIssue(ctx, r, fmt.Sprintf(wikiMarker, wp.page.GetPageName()), ghc)
+	if err != nil {
+		return err
+	}
+
+	req := &github.IssueRequest{
+		Title: github.String(fmt.Sprintf(wikiIssueTitle, wp.page.GetTitle())),
+		Body:  github.String(b.wikiReport(wp)),
+	}
+	switch {
+	case existing == nil && len(wp.findings) == 0:
+		return nil
+	case existing == nil:
+		_, _, err = ghc.Issues.Create(ctx, owner, name, req)
+	default:
+		if len(wp.findings) == 0 {
+			req.State = github.String("closed")
+		}
+		_, _, err = ghc.Issues.Edit(ctx, owner, name, existing.GetNumber(), req)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to write issue for wiki page %s: %s", wp.page.GetPageName(), err)
+	}
+	log.Ctx(ctx).Info().Str("Repo", r.GetFullName()).Str("Page", wp.page.GetPageName()).Msgf("Reported %d flagged line(s) in an issue", len(wp.findings))
+	return nil
+}
+
+// wikiDir returns the directory of the local clone of a repo's wiki
+func (b *Bot) wikiDir(r *github.Repository) string {
+	return filepath.Join(b.wikiCloneDir, r.GetOwner().GetLogin(), r.GetName()+".wiki.git")
+}
+
+// wikiLock returns the lock guarding the wiki clone in dir, creating it on first use
+func (b *Bot) wikiLock(dir string) *sync.Mutex {
+	b.wikiMu.Lock()
+	defer b.wikiMu.Unlock()
+
+	mu, ok := b.wikiLocks[dir]
+	if !ok {
+		mu = &sync.Mutex{}
+		b.wikiLocks[dir] = mu
+	}
+	return mu
+}
+
+// syncWiki brings the local clone of a repo's wiki in dir up to date, cloning it first if needed. The token is passed
+// as a header for each command so it is never written to the clone's configuration.
+func syncWiki(ctx context.Context, r *github.Repository, dir, token string) error {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(dir), 0700); err != nil {
+			return fmt.Errorf("Failed to create %s: %s", filepath.Dir(dir), err)
+		}
+		_, err := git(ctx, "", token, "clone", "--bare", "--quiet", r.GetHTMLURL()+".wiki.git", dir)
+		return err
+	}
+
+	_, err := git(ctx, dir, token, "fetch", "--quiet", "--prune", "origin", "+refs/heads/*:refs/heads/*")
+	return err
+}
+
+// readWikiPage returns the file name and content of a wiki page at the revision in the event. Gollum stores pages as
+// files named after the page, with an extension for the markup language.
+func readWikiPage(ctx context.Context, dir string, p *github.Page) (string, string, error) {
+	files, err := git(ctx, dir, "", "ls-tree", "-r", "--name-only", p.GetSHA())
+	if err != nil {
+		return "", "", err
+	}
+
+	for _, f := range strings.Split(strings.TrimSpace(files), "\n") {
+		base := path.Base(f)
+		if strings.TrimSuffix(base, path.Ext(base)) != p.GetPageName() {
+			continue
+		}
+
+		content, err := git(ctx, dir, "", "show", p.GetSHA()+":"+f)
+		return f, content, err
+	}
+
+	return "", "", fmt.Errorf("No file found for page %s at %s", p.GetPageName(), p.GetSHA())
+}
+
+// checkGitVersion returns an error unless the installed git is recent enough to check wikis. Tokens are passed to git
+// through GIT_CONFIG_COUNT, which older versions ignore, failing to authenticate.
+func checkGitVersion(ctx context.Context) error {
+	out, err := git(ctx, "", "", "--version")
+	if err != nil {
+		return fmt.Errorf("Failed to find git, which checking wikis requires: %s", err)
+	}
+
+	major, minor, err := parseGitVersion(out)
+	if err != nil {
+		return err
+	}
+	if major < 2 || major == 2 && minor < 31 {
+		return fmt.Errorf("Checking wikis requires git 2.31 or later, found %d.%d", major, minor)
+	}
+	return nil
+}
+
+// parseGitVersion returns the major and minor version from the output of git --version
+func parseGitVersion(out string) (int, int, error) {
+	m := gitVersionPattern.FindStringSubmatch(strings.TrimSpace(out))
+	if m == nil {
+		return 0, 0, fmt.Errorf("Failed to parse git version from %q", strings.TrimSpace(out))
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	return major, minor, nil
+}
+
+// git runs a git command in dir, returning its output. Requests to the remote are authenticated with token when set.
+func git(ctx context.Context, dir, token string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if token != "" {
+		// Configuration from the environment stays out of the command line, which other processes can read
+		auth := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+		cmd.Env = append(cmd.Env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+auth,
+		)
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("Failed to run git %s: %s: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// wikiReport renders the report of flagged terms found in a wiki page
+func (b *Bot) wikiReport(wp wikiPage) string {
+	var sb strings.Builder
+
+	page := fmt.Sprintf("[%s](%s)", wp.page.GetTitle(), wp.page.GetHTMLURL())
+	if len(wp.findings) == 0 {
+		fmt.Fprintf(&sb, "### %s\n\n%s Thanks for updating, no flagged terms are used in %s anymore.\n", b.checkName, b.checkSuccessSummary, page)
+	} else {
+		fmt.Fprintf(&sb, "### %s\n\n%s\n\n%s\n\n", b.checkName, b.checkFailureSummary, page)
+		fmt.Fprint(&sb, "| Line | Term | Suggestion |\n| --- | --- | --- |\n")
+		for _, f := range wp.findings {
+			for _, m := range f.terms {
+				suggestion := ""
+				if t, ok := b.lookupTerm(m); ok {
+					suggestion = strings.Join(t.alternatives, ", ")
+				}
+				fmt.Fprintf(&sb, "| %d | `%s` | %s |\n", f.line, m, suggestion)
+			}
+		}
+	}
+	fmt.Fprintf(&sb, "\n%s\n", fmt.Sprintf(wikiMarker, wp.page.GetPageName()))
+
+	return sb.String()
+}
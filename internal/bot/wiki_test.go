@@ -0,0 +1,207 @@
+package bot
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/zendesk/term-check/internal/githubtest"
+)
+
+func TestGitPassesTokenThroughEnvironment(t *testing.T) {
+	out, err := git(context.Background(), "", "secret", "config", "--get", "http.extraHeader")
+	if assert.NoError(t, err) {
+		auth := base64.StdEncoding.EncodeToString([]byte("x-access-token:secret"))
+		assert.Equal(t, "Authorization: Basic "+auth, strings.TrimSpace(out))
+	}
+}
+
+func TestWikiLockIsPerClone(t *testing.T) {
+	b := &Bot{wikiCloneDir: "/var/wikis", wikiLocks: make(map[string]*sync.Mutex)}
+	other := &github.Repository{Name: github.String("app"), Owner: &github.User{Login: github.String("zendesk")}}
+
+	dir := b.wikiDir(testRepo)
+	assert.Equal(t, "/var/wikis/zendesk/term-check.wiki.git", dir)
+	assert.Same(t, b.wikiLock(dir), b.wikiLock(dir))
+
+	// Holding the lock of one clone doesn't hold up another
+	b.wikiLock(dir).Lock()
+	defer b.wikiLock(dir).Unlock()
+	assert.NotSame(t, b.wikiLock(dir), b.wikiLock(b.wikiDir(other)))
+	b.wikiLock(b.wikiDir(other)).Lock()
+	b.wikiLock(b.wikiDir(other)).Unlock()
+}
+
+func TestReadWikiPage(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "wiki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	run := func(args ...string) string {
+		out, err := git(ctx, dir, "", append([]string{"-c", "user.name=Term Check", "-c", "user.email=term-check@example.com"}, args...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(out)
+	}
+	run("init", "--quiet")
+	if err := ioutil.WriteFile(filepath.Join(dir, "Home.md"), []byte("Use the master branch\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "Home-Page.md"), []byte("Unrelated\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	run("add", ".")
+	run("commit", "--quiet", "-m", "Add pages")
+	sha := run("rev-parse", "HEAD")
+
+	file, content, err := readWikiPage(ctx, dir, &github.Page{PageName: github.String("Home"), SHA: github.String(sha)})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Home.md", file)
+		assert.Equal(t, "Use the master branch\n", content)
+	}
+
+	_, _, err = readWikiPage(ctx, dir, &github.Page{PageName: github.String("Missing"), SHA: github.String(sha)})
+	assert.Error(t, err)
+}
+
+type openWikiPageIssueTestCase struct {
+	name             string
+	issues           string
+	findings         []finding
+	expectedRequests []string
+	expectedState    interface{}
+}
+
+func TestOpenWikiPageIssue(t *testing.T) {
+	b := &Bot{
+		appSlug:             "term-check",
+		checkName:           "term-check",
+		checkSuccessSummary: "All good.",
+		checkFailureSummary: "Flagged terms found.",
+		terms:               []term{{source: "master", pattern: regexp.MustCompile("master"), alternatives: []string{"main"}}},
+	}
+	page := &github.Page{PageName: github.String("Home"), Title: github.String("Home"), HTMLURL: github.String("https://github.com/zendesk/term-check/wiki/Home")}
+	// The issue of another page, and one opened by someone else, are not the page's report
+	issues := `[
+		{"number": 1, "user": {"login": "term-check[bot]", "type": "Bot"}, "body": "<!-- term-check:wiki page=Home-Page -->"},
+		{"number": 2, "user": {"login": "mallory", "type": "User"}, "body": "<!-- term-check:wiki page=Home -->"},
+		{"number": 3, "user": {"login": "term-check[bot]", "type": "Bot"}, "body": "<!-- term-check:wiki page=Home -->"}
+	]`
+	findings := []finding{{path: "Home.md", line: 1, terms: []string{"master"}}}
+
+	cases := []openWikiPageIssueTestCase{
+		{
+			name:     "OpensIssue",
+			issues:   `[]`,
+			findings: findings,
+			expectedRequests: []string{
+				"GET /repos/zendesk/term-check/issues",
+				"POST /repos/zendesk/term-check/issues",
+			},
+		},
+		{
+			name:     "UpdatesIssue",
+			issues:   issues,
+			findings: findings,
+			expectedRequests: []string{
+				"GET /repos/zendesk/term-check/issues",
+				"PATCH /repos/zendesk/term-check/issues/3",
+			},
+		},
+		{
+			name:     "ClosesIssueOnceClean",
+			issues:   issues,
+			findings: nil,
+			expectedRequests: []string{
+				"GET /repos/zendesk/term-check/issues",
+				"PATCH /repos/zendesk/term-check/issues/3",
+			},
+			expectedState: "closed",
+		},
+		{
+			name:     "NothingToReport",
+			issues:   `[]`,
+			findings: nil,
+			expectedRequests: []string{
+				"GET /repos/zendesk/term-check/issues",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := githubtest.NewServer(t)
+			s.Respond("GET /repos/zendesk/term-check/issues", http.StatusOK, tc.issues)
+			s.Respond("POST /repos/zendesk/term-check/issues", http.StatusCreated, `{"number": 4}`)
+			s.Respond("PATCH /repos/zendesk/term-check/issues/3", http.StatusOK, `{"number": 3}`)
+
+			err := b.openWikiPageIssue(context.Background(), wikiPage{page: page, findings: tc.findings}, testRepo, s.Client)
+
+			assert.NoError(t, err)
+			requests := s.Requests()
+			assert.Equal(t, tc.expectedRequests, s.Routes())
+			if len(requests) > 1 {
+				assert.Equal(t, "Flagged terms in wiki page Home", requests[1].Body["title"])
+				assert.Contains(t, requests[1].Body["body"], "<!-- term-check:wiki page=Home -->")
+				assert.Equal(t, tc.expectedState, requests[1].Body["state"])
+			}
+		})
+	}
+}
+
+func TestCheckWikiRefusesWithoutSuitableGit(t *testing.T) {
+	s := githubtest.NewServer(t)
+	s.Respond("GET /repos/zendesk/term-check/contents/.github/term-check.yaml", http.StatusOK, githubtest.Contents("wiki:\n  enabled: true"))
+
+	// No client is set, the bot stops before getting a token to clone the wiki with
+	b := &Bot{wikiGitErr: errors.New("Checking wikis requires git 2.31 or later, found 2.30")}
+	b.checkWiki(context.Background(), []*github.Page{{PageName: github.String("Home")}}, testRepo, 1, s.Client)
+
+	assert.Equal(t, []string{"GET /repos/zendesk/term-check/contents/.github/term-check.yaml"}, s.Routes())
+}
+
+type parseGitVersionTestCase struct {
+	name          string
+	out           string
+	expectedMajor int
+	expectedMinor int
+	expectedErr   bool
+}
+
+func TestParseGitVersion(t *testing.T) {
+	cases := []parseGitVersionTestCase{
+		{name: "Linux", out: "git version 2.31.1\n", expectedMajor: 2, expectedMinor: 31},
+		{name: "MacOS", out: "git version 2.39.3 (Apple Git-145)\n", expectedMajor: 2, expectedMinor: 39},
+		{name: "Windows", out: "git version 2.40.1.windows.1\n", expectedMajor: 2, expectedMinor: 40},
+		{name: "Old", out: "git version 1.8.3.1\n", expectedMajor: 1, expectedMinor: 8},
+		{name: "Unknown", out: "not git\n", expectedErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			major, minor, err := parseGitVersion(tc.out)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expectedMajor, major)
+				assert.Equal(t, tc.expectedMinor, minor)
+			}
+		})
+	}
+}
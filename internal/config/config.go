@@ -5,8 +5,13 @@ package config
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v2"
 
@@ -16,37 +21,146 @@ import (
 	"github.com/zendesk/term-check/pkg/config"
 )
 
-const repoConfigFileLocation = "./.github/term-check.yaml"
+// RepoConfigPath is the path of the configuration file within a repository
+const RepoConfigPath = ".github/term-check.yaml"
+
+const repoConfigFileLocation = "./" + RepoConfigPath
+
+// Organization wide configuration lives in the organization's `.github` repository
+const (
+	orgConfigRepo         = ".github"
+	orgConfigFileLocation = "./term-check.yaml"
+)
+
+// Enforcement modes, deciding the conclusion of check runs with findings
+const (
+	AdvisoryMode       = "advisory"
+	ActionRequiredMode = "action_required"
+	BlockingMode       = "blocking"
+)
 
 // TODO: write Unmarshal() to require values
 
 // BotConfig holds all config values necessary for the BotConfig
 type BotConfig struct {
-	AppID               int      `yaml:"appID"`
-	TermList            []string `yaml:"termList"`
-	CheckName           string   `yaml:"checkName"`
-	CheckSuccessSummary string   `yaml:"checkSuccessSummary"`
-	CheckFailureSummary string   `yaml:"checkFailureSummary"`
-	CheckDetails        string   `yaml:"checkDetails"`
-	AnnotationTitle     string   `yaml:"annotationTitle"`
-	AnnotationBody      string   `yaml:"annotationBody"`
+	AppID               int    `yaml:"appID"`
+	TermList            []Term `yaml:"termList"`
+	CheckName           string `yaml:"checkName"`
+	CheckSuccessSummary string `yaml:"checkSuccessSummary"`
+	CheckFailureSummary string `yaml:"checkFailureSummary"`
+	CheckDetails        string `yaml:"checkDetails"`
+	AnnotationTitle     string `yaml:"annotationTitle"`
+	AnnotationBody      string `yaml:"annotationBody"`
+	SuggestChanges      bool   `yaml:"suggestChanges"`
+	SummaryComment      bool   `yaml:"summaryComment"`
+	Onboarding          bool   `yaml:"onboarding"`
+	Mode                string `yaml:"mode"`
+	ScanIssues          bool   `yaml:"scanIssues"`
+	WikiCloneDir        string `yaml:"wikiCloneDir"`
+	AuditRepo           string `yaml:"auditRepo"`
+	AuditToken          string `yaml:"-"`
+	UploadSARIF         bool   `yaml:"uploadSARIF"`
+}
+
+// Term is a flagged term along with its suggested alternatives and an optional category. In the configuration it can
+// either be a plain string, or a mapping with `term`, `alternatives` and `category` keys
+type Term struct {
+	Term         string   `yaml:"term"`
+	Alternatives []string `yaml:"alternatives"`
+	Category     string   `yaml:"category"`
 }
 
-// ClientConfig holds all config values necessary for the client
+// UnmarshalYAML allows a Term to be written as a plain string
+func (t *Term) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&t.Term); err == nil {
+		return nil
+	}
+
+	type plain Term
+	return unmarshal((*plain)(t))
+}
+
+// ClientConfig holds all config values necessary for the client. BaseURL and UploadURL are only set for GitHub
+// Enterprise Server. Token is read from the secrets, and replaces AppID and PrivateKeyPath when set
 type ClientConfig struct {
 	AppID          int    `yaml:"appID"`
 	PrivateKeyPath string `yaml:"privateKeyPath"`
+	BaseURL        string `yaml:"baseURL"`
+	UploadURL      string `yaml:"uploadURL"`
+	Token          string `yaml:"-"`
 }
 
-// ServerConfig holds all config values necessary for the server
+// ServerConfig holds all config values necessary for the server. WebhookSecretKey, TLSCert and TLSKey are read from the
+// secrets, and HTTPS is served when TLSCert and TLSKey are set
 type ServerConfig struct {
-	WebhookSecretKey string `yaml:"webhookSecretKey"`
+	WebhookSecretKey    string        `yaml:"-"`
+	TLSCert             string        `yaml:"-"`
+	TLSKey              string        `yaml:"-"`
+	Address             string        `yaml:"address"`
+	Port                int           `yaml:"port"`
+	ReadTimeout         time.Duration `yaml:"readTimeout"`
+	WriteTimeout        time.Duration `yaml:"writeTimeout"`
+	IdleTimeout         time.Duration `yaml:"idleTimeout"`
+	WebhookPath         string        `yaml:"webhookPath"`
+	Workers             int           `yaml:"workers"`
+	QueueDepth          int           `yaml:"queueDepth"`
+	JobTimeout          time.Duration `yaml:"jobTimeout"`
+	ShutdownGracePeriod time.Duration `yaml:"shutdownGracePeriod"`
+	DedupTTL            time.Duration `yaml:"dedupTTL"`
 }
 
 // RepoConfig is an object holding all configuration values for one repo
 // ignore - array of paths following `.gitignore` rules to ignore in the term check
+// suggestChanges - overrides the bot's default for posting suggested changes as review comments
+// summaryComment - overrides the bot's default for keeping a summary comment on pull requests
+// labels - labels to apply to pull requests with findings
+// mode - enforcement mode, overriding the bot's default
+// scanIssues - overrides the bot's default for checking issues and discussions
+// wiki - checking of wiki pages as they are edited
+// releases - checking of releases and tags as they are published
+// uploadSARIF - overrides the bot's default for uploading findings to code scanning
 type RepoConfig struct {
-	Ignore []string `yaml:"ignore"`
+	Ignore         []string      `yaml:"ignore"`
+	SuggestChanges *bool         `yaml:"suggestChanges"`
+	SummaryComment *bool         `yaml:"summaryComment"`
+	Labels         LabelConfig   `yaml:"labels"`
+	Mode           string        `yaml:"mode"`
+	ScanIssues     *bool         `yaml:"scanIssues"`
+	Wiki           WikiConfig    `yaml:"wiki"`
+	Releases       ReleaseConfig `yaml:"releases"`
+	UploadSARIF    *bool         `yaml:"uploadSARIF"`
+}
+
+// OrgConfig is an object holding all configuration values for one organization, read from `term-check.yaml` in the
+// organization's `.github` repository
+// minimumMode - strictest enforcement mode repos in the organization cannot go below
+type OrgConfig struct {
+	MinimumMode string `yaml:"minimumMode"`
+}
+
+// LabelConfig names the labels applied to pull requests while they have findings
+// findings - label applied whenever there are findings
+// categories - map of term category to the label applied when terms of that category are found
+type LabelConfig struct {
+	Findings   string            `yaml:"findings"`
+	Categories map[string]string `yaml:"categories"`
+}
+
+// WikiConfig controls the checking of wiki pages
+// enabled - whether edited wiki pages are checked
+// issue - number of the issue findings are reported on as comments. A new issue is opened for each edit when unset
+type WikiConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Issue   int  `yaml:"issue"`
+}
+
+// ReleaseConfig controls the checking of releases and tags
+// enabled - whether release names, release notes and tag names are checked
+// issue - number of the tracking issue findings are reported on as comments. Findings are reported as a check run on
+// the tagged commit when unset
+type ReleaseConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Issue   int  `yaml:"issue"`
 }
 
 // Config holds all config values for the application, separated by module
@@ -100,31 +214,55 @@ func New(configFilepath string) *Config {
 	}
 }
 
-// GetRepoConfig retreives the configuration for a repository
-func GetRepoConfig(ctx context.Context, repo *github.Repository, head string, client *github.Client) *RepoConfig {
+// GetRepoConfig retreives the configuration for a repository. A missing configuration file results in an empty
+// configuration, while a configuration file that cannot be fetched or parsed results in an error.
+func GetRepoConfig(ctx context.Context, repo *github.Repository, head string, client *github.Client) (*RepoConfig, error) {
 	config := RepoConfig{}
-	var rawConfig string
+	_, err := getConfigFile(ctx, client, repo.GetOwner().GetLogin(), repo.GetName(), repoConfigFileLocation, head, &config)
+	return &config, err
+}
+
+// GetOrgConfig retreives the configuration for an organization, in the same way as GetRepoConfig. The app needs access
+// to the organization's `.github` repository to read it, and GitHub answers a missing file and a repository the app
+// can't access alike, so a warning is logged when the repository can't be found.
+func GetOrgConfig(ctx context.Context, owner string, client *github.Client) (*OrgConfig, error) {
+	config := OrgConfig{}
+	found, err := getConfigFile(ctx, client, owner, orgConfigRepo, orgConfigFileLocation, "", &config)
+	if err != nil || found {
+		return &config, err
+	}
+
+	if _, resp, err := client.Repositories.Get(ctx, owner, orgConfigRepo); err != nil && resp != nil && resp.StatusCode == http.StatusNotFound {
+		log.Ctx(ctx).Warn().Str("Owner", owner).Msgf("Failed to find %s/%s, no minimum mode is enforced. Give the app access to it if it exists", owner, orgConfigRepo)
+	}
+	return &config, nil
+}
+
+// getConfigFile reads and parses a YAML configuration file from a repository into config, returning whether the file
+// was there. config is left untouched if the file is not there.
+func getConfigFile(ctx context.Context, client *github.Client, owner, repo, path, ref string, config interface{}) (bool, error) {
+	fc, _, resp, err := client.Repositories.GetContents(ctx, owner, repo, path, &github.RepositoryContentGetOptions{Ref: ref})
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("Failed to get %s from %s/%s: %s", path, owner, repo, err)
+	}
 
-	fc, _, resp, err := client.Repositories.GetContents(
-		ctx,
-		repo.GetOwner().GetLogin(),
-		repo.GetName(),
-		repoConfigFileLocation,
-		&github.RepositoryContentGetOptions{Ref: head},
-	)
-	if err == nil {
-		rawConfig, err = fc.GetContent()
+	rawConfig, err := fc.GetContent()
+	if err != nil {
+		return true, fmt.Errorf("Failed to decode %s from %s/%s: %s", path, owner, repo, err)
 	}
 
-	// Store empty configuration if error or file is not there
-	if err == nil && resp.StatusCode == http.StatusOK {
-		err = yaml.Unmarshal([]byte(rawConfig), &config)
-		if err != nil {
-			panic(err)
-		}
+	if err := yaml.Unmarshal([]byte(rawConfig), config); err != nil {
+		return true, fmt.Errorf("Failed to parse %s from %s/%s: %s", path, owner, repo, err)
 	}
+	return true, nil
+}
 
-	return &config
+// ValidMode returns whether the passed in string is a known enforcement mode
+func ValidMode(mode string) bool {
+	return mode == AdvisoryMode || mode == ActionRequiredMode || mode == BlockingMode
 }
 
 func panic(err error) {
@@ -147,6 +285,23 @@ func (c *Config) getBotConfig(config []byte) (*BotConfig, error) {
 		return &BotConfig{}, errors.New("TERM_LIST must contain at least one item")
 	}
 
+	if bc.Mode == "" {
+		bc.Mode = AdvisoryMode
+	}
+	if !ValidMode(bc.Mode) {
+		return &BotConfig{}, fmt.Errorf("mode must be one of %s, %s or %s", AdvisoryMode, ActionRequiredMode, BlockingMode)
+	}
+
+	if bc.WikiCloneDir == "" {
+		bc.WikiCloneDir = filepath.Join(os.TempDir(), "term-check-wikis")
+	}
+
+	if bc.AuditRepo == "" {
+		bc.AuditRepo = orgConfigRepo
+	}
+	// The audit endpoints are only served when a token to protect them is set
+	bc.AuditToken = c.secretHash["AUDIT_TOKEN"]
+
 	return &bc, nil
 }
 
@@ -162,17 +317,53 @@ func (c *Config) getClientConfig(config []byte) (*ClientConfig, error) {
 	}
 	cc := d.C
 
+	// Deployments that cannot register an application authenticate with a personal access token instead
+	cc.Token = c.secretHash["GITHUB_TOKEN"]
+
 	return &cc, nil
 }
 
 func (c *Config) getServerConfig(config []byte) (*ServerConfig, error) {
+	type driver struct {
+		S ServerConfig `yaml:"serverConfig"`
+	}
+
+	d := driver{}
+	err := yaml.Unmarshal(config, &d)
+	if err != nil {
+		panic(err)
+	}
+	sc := d.S
+
 	ws, ok := c.secretHash["WEBHOOK_SECRET_KEY"]
 
 	if !ok {
 		return &ServerConfig{}, errors.New("WEBHOOK_SECRET_KEY not present in secrets hash")
 	}
+	sc.WebhookSecretKey = ws
+
+	cert, hasCert := c.secretHash["TLS_CERT"]
+	key, hasKey := c.secretHash["TLS_KEY"]
+	if hasCert != hasKey {
+		return &ServerConfig{}, errors.New("TLS_CERT and TLS_KEY must be present in secrets hash together")
+	}
+	sc.TLSCert, sc.TLSKey = cert, key
+
+	if sc.Address == "" {
+		sc.Address = "0.0.0.0"
+	}
+	if sc.Port == 0 {
+		sc.Port = 8080
+	}
+	if sc.WebhookPath == "" {
+		sc.WebhookPath = "/"
+	}
+	if !strings.HasPrefix(sc.WebhookPath, "/") {
+		return &ServerConfig{}, errors.New("webhookPath must start with /")
+	}
+	if sc.DedupTTL <= 0 {
+		sc.DedupTTL = time.Hour
+	}
 
-	return &ServerConfig{
-		WebhookSecretKey: ws,
-	}, nil
+	return &sc, nil
 }
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/zendesk/term-check/internal/githubtest"
+)
+
+type getOrgConfigTestCase struct {
+	name        string
+	file        string
+	repoStatus  int
+	expected    *OrgConfig
+	expectedLog bool
+}
+
+func TestGetOrgConfig(t *testing.T) {
+	cases := []getOrgConfigTestCase{
+		{
+			name:       "ReadsFile",
+			file:       "minimumMode: blocking",
+			repoStatus: http.StatusOK,
+			expected:   &OrgConfig{MinimumMode: BlockingMode},
+		},
+		{
+			name:       "NoFile",
+			repoStatus: http.StatusOK,
+			expected:   &OrgConfig{},
+		},
+		{
+			name:        "RepoNotFound",
+			repoStatus:  http.StatusNotFound,
+			expected:    &OrgConfig{},
+			expectedLog: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := githubtest.NewServer(t)
+			if tc.file != "" {
+				s.Respond("GET /repos/zendesk/.github/contents/term-check.yaml", http.StatusOK, githubtest.Contents(tc.file))
+			}
+			s.Respond("GET /repos/zendesk/.github", tc.repoStatus, `{"name": ".github"}`)
+
+			var logs bytes.Buffer
+			ctx := zerolog.New(&logs).WithContext(context.Background())
+
+			oc, err := GetOrgConfig(ctx, "zendesk", s.Client)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, oc)
+			}
+			// Without the repository, the floor may exist but can't be read
+			assert.Equal(t, tc.expectedLog, bytes.Contains(logs.Bytes(), []byte(`"level":"warn"`)))
+		})
+	}
+}
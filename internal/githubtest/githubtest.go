@@ -0,0 +1,119 @@
+// Package githubtest provides a fake GitHub API for testing code that talks to GitHub.
+package githubtest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v32/github"
+)
+
+// Server is a fake GitHub API. Requests are answered by the handler registered for their route, a method and path
+// such as "GET /repos/zendesk/term-check", and requests to routes without one get a 404 as missing resources do.
+// Every request is recorded along with its body.
+type Server struct {
+	// Client is a client sending its requests to the server
+	Client *github.Client
+
+	server   *httptest.Server
+	mu       sync.Mutex
+	handlers map[string]http.HandlerFunc
+	requests []Request
+}
+
+// Request is a request received by the server
+type Request struct {
+	Route  string
+	Header http.Header
+	// Body is the decoded JSON body of the request, nil if it had none
+	Body map[string]interface{}
+}
+
+// NewServer starts a fake GitHub API, closed when the test finishes
+func NewServer(t *testing.T) *Server {
+	s := &Server{handlers: make(map[string]http.HandlerFunc)}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.server.Close)
+
+	s.Client = github.NewClient(nil)
+	s.Client.BaseURL, _ = url.Parse(s.server.URL + "/")
+	return s
+}
+
+// Handle registers the handler answering requests to the route
+func (s *Server) Handle(route string, handler http.HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[route] = handler
+}
+
+// Respond registers a handler answering requests to the route with the status and body
+func (s *Server) Respond(route string, status int, body string) {
+	s.Handle(route, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	})
+}
+
+// Requests returns the requests received so far, in order
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Routes returns the routes of the requests received so far, in order
+func (s *Server) Routes() []string {
+	var routes []string
+	for _, r := range s.Requests() {
+		routes = append(routes, r.Route)
+	}
+	return routes
+}
+
+// Body returns the body of the last request received to the route, nil if there was none
+func (s *Server) Body(route string) map[string]interface{} {
+	requests := s.Requests()
+	for i := len(requests) - 1; i >= 0; i-- {
+		if requests[i].Route == route {
+			return requests[i].Body
+		}
+	}
+	return nil
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	route := fmt.Sprintf("%s %s", r.Method, r.URL.Path)
+
+	// The body is put back so handlers can read it too
+	raw, _ := ioutil.ReadAll(r.Body)
+	r.Body = ioutil.NopCloser(bytes.NewReader(raw))
+	var body map[string]interface{}
+	json.Unmarshal(raw, &body)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Route: route, Header: r.Header, Body: body})
+	handler, ok := s.handlers[route]
+	s.mu.Unlock()
+
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message": "Not Found"}`)
+		return
+	}
+	handler(w, r)
+}
+
+// Contents returns the body of a response to a request for the contents of a file holding content
+func Contents(content string) string {
+	encoded := base64.StdEncoding.EncodeToString([]byte(content))
+	return fmt.Sprintf(`{"type": "file", "encoding": "base64", "content": %q}`, encoded)
+}
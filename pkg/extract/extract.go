@@ -0,0 +1,74 @@
+// Package extract provides extractors that pull the human-readable text out of the lines added to a file, so that
+// structured formats such as notebooks and markup can be checked without matching on their syntax
+package extract
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Line is a single line added to a file, along with its line number in the new version of the file
+type Line struct {
+	Number  int
+	Content string
+}
+
+// Segment is a piece of human-readable text, along with the number of the line it was extracted from. Start and End are
+// the byte offsets of the part of the line's content the text was taken from, before any unescaping.
+type Segment struct {
+	Line  int
+	Text  string
+	Start int
+	End   int
+}
+
+// Extractor pulls the human-readable text out of the added lines of a file. Lines are passed in ascending order, and
+// every Segment returned must refer back to the number of one of the passed in lines. Segments with an empty range
+// between Start and End are still checked, but their lines are not rewritten by fixes.
+type Extractor interface {
+	Extract(lines []Line) []Segment
+}
+
+var (
+	mu         sync.RWMutex
+	extractors = map[string]Extractor{
+		".ipynb": Notebook{},
+		".svg":   Markup{},
+		".html":  Markup{},
+		".htm":   Markup{},
+		".xhtml": Markup{},
+		".xml":   Markup{},
+	}
+)
+
+// Register sets the Extractor used for files with the passed in extension, e.x. ".svg"
+func Register(extension string, e Extractor) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	extractors[strings.ToLower(extension)] = e
+}
+
+// ForFile returns the Extractor registered for the extension of the passed in filename, falling back to Text
+func ForFile(filename string) Extractor {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if e, ok := extractors[strings.ToLower(filepath.Ext(filename))]; ok {
+		return e
+	}
+	return Text{}
+}
+
+// Text is an Extractor for plain text files, returning every line as is
+type Text struct{}
+
+// Extract returns one Segment per line holding the line's full content
+func (Text) Extract(lines []Line) []Segment {
+	segments := make([]Segment, 0, len(lines))
+	for _, l := range lines {
+		segments = append(segments, Segment{Line: l.Number, Text: l.Content, Start: 0, End: len(l.Content)})
+	}
+	return segments
+}
@@ -0,0 +1,71 @@
+package extract
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type extractTestCase struct {
+	name     string
+	filename string
+	lines    []Line
+	expected []Segment
+}
+
+func TestExtract(t *testing.T) {
+	cases := []extractTestCase{
+		{
+			name:     "TextReturnsLines",
+			filename: "main.go",
+			lines:    []Line{{Number: 3, Content: "// whitelist"}, {Number: 4, Content: "func a() {}"}},
+			expected: []Segment{{Line: 3, Text: "// whitelist", Start: 0, End: 12}, {Line: 4, Text: "func a() {}", Start: 0, End: 11}},
+		},
+		{
+			name:     "NotebookUnescapesSource",
+			filename: "analysis.ipynb",
+			lines: []Line{
+				{Number: 10, Content: `    "cell_type": "markdown",`},
+				{Number: 11, Content: `    "# The \"master\" list\n",`},
+				{Number: 12, Content: `      "image/png": "bWFzdGVy",`},
+				{Number: 13, Content: `   "source": ["print('slave')"]`},
+			},
+			expected: []Segment{
+				{Line: 11, Text: "# The \"master\" list\n", Start: 5, End: 28},
+				{Line: 13, Text: "print('slave')", Start: 15, End: 29},
+			},
+		},
+		{
+			name:     "MarkupDropsTags",
+			filename: "logo.SVG",
+			lines: []Line{
+				{Number: 1, Content: `<svg id="master"><text x="1">Black &amp; white</text>`},
+				{Number: 2, Content: `<g class="slave"`},
+				{Number: 3, Content: `   fill="none">whitelist<!-- TODO --></g></svg>`},
+			},
+			expected: []Segment{
+				{Line: 1, Text: "Black & white", Start: 29, End: 46},
+				{Line: 3, Text: "whitelist", Start: 15, End: 24},
+				{Line: 3, Text: "TODO", Start: 28, End: 34},
+			},
+		},
+		{
+			name:     "MarkupResetsBetweenHunks",
+			filename: "index.html",
+			lines: []Line{
+				{Number: 1, Content: `<a href="#"`},
+				{Number: 20, Content: `blacklist <b>here</b>`},
+			},
+			expected: []Segment{
+				{Line: 20, Text: "blacklist", Start: 0, End: 10},
+				{Line: 20, Text: "here", Start: 13, End: 17},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ForFile(tc.filename).Extract(tc.lines))
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package extract
+
+import (
+	"html"
+	"strings"
+)
+
+// Markup is an Extractor for SVG, HTML and XML files. It drops tags along with their attributes and returns the text
+// nodes in between, with entities decoded. Comment contents are kept as text. Tags may span multiple lines as long as
+// the lines are consecutive.
+type Markup struct{}
+
+// markupState tracks where a scan of markup left off at the end of a line
+type markupState int
+
+const (
+	inText markupState = iota
+	inTag
+	inComment
+)
+
+// Extract returns one Segment per run of text found on each line
+func (Markup) Extract(lines []Line) []Segment {
+	var segments []Segment
+
+	state := inText
+	var quote byte
+	for i, l := range lines {
+		// Lines that don't follow on from the previous one start from a clean slate, as the context between them
+		// is unknown
+		if i > 0 && l.Number != lines[i-1].Number+1 {
+			state, quote = inText, 0
+		}
+
+		c := l.Content
+
+		// The text of a run is kept along with the range of the line it was read from
+		var text strings.Builder
+		start, end := 0, 0
+		write := func(j int) {
+			if text.Len() == 0 {
+				start = j
+			}
+			text.WriteByte(c[j])
+			end = j + 1
+		}
+		flush := func() {
+			if t := strings.TrimSpace(text.String()); t != "" {
+				segments = append(segments, Segment{Line: l.Number, Text: html.UnescapeString(t), Start: start, End: end})
+			}
+			text.Reset()
+		}
+
+		for j := 0; j < len(c); j++ {
+			switch state {
+			case inTag:
+				switch {
+				case quote != 0:
+					if c[j] == quote {
+						quote = 0
+					}
+				case c[j] == '"' || c[j] == '\'':
+					quote = c[j]
+				case c[j] == '>':
+					state = inText
+				}
+			case inComment:
+				if strings.HasPrefix(c[j:], "-->") {
+					flush()
+					state = inText
+					j += len("-->") - 1
+				} else {
+					write(j)
+				}
+			default:
+				switch {
+				case strings.HasPrefix(c[j:], "<!--"):
+					flush()
+					state = inComment
+					j += len("<!--") - 1
+				case strings.HasPrefix(c[j:], "<![CDATA["):
+					flush()
+					j += len("<![CDATA[") - 1
+				case strings.HasPrefix(c[j:], "]]>"):
+					flush()
+					j += len("]]>") - 1
+				case c[j] == '<' && j+1 < len(c) && isTagStart(c[j+1]):
+					flush()
+					state = inTag
+				default:
+					write(j)
+				}
+			}
+		}
+		flush()
+	}
+
+	return segments
+}
+
+func isTagStart(b byte) bool {
+	return b == '/' || b == '!' || b == '?' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
@@ -0,0 +1,73 @@
+package extract
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// notebookTextKeys holds the keys whose string values are human-readable in a Jupyter notebook. String values without
+// a key on the same line are array elements, which is how cell sources and text outputs are stored.
+var notebookTextKeys = map[string]struct{}{
+	"source": {},
+	"text":   {},
+}
+
+// Notebook is an Extractor for Jupyter notebooks (.ipynb). It unescapes the JSON strings holding cell sources and
+// text outputs, skipping keys and values like cell metadata or base64 encoded images.
+type Notebook struct{}
+
+// Extract returns one Segment per human-readable JSON string found on each line
+func (Notebook) Extract(lines []Line) []Segment {
+	var segments []Segment
+
+	for _, l := range lines {
+		key := ""
+		for rest := l.Content; ; {
+			literal, after, ok := nextJSONString(rest)
+			if !ok {
+				break
+			}
+			rest = after
+
+			var s string
+			if err := json.Unmarshal([]byte(literal), &s); err != nil {
+				break
+			}
+
+			if trimmed := strings.TrimLeft(rest, " \t"); strings.HasPrefix(trimmed, ":") {
+				key = s
+				rest = trimmed[1:]
+				continue
+			}
+
+			if _, ok := notebookTextKeys[key]; key == "" || ok {
+				// The range covers the literal without its quotes
+				end := len(l.Content) - len(rest) - 1
+				segments = append(segments, Segment{Line: l.Number, Text: s, Start: end - len(literal) + 2, End: end})
+			}
+			key = ""
+		}
+	}
+
+	return segments
+}
+
+// nextJSONString finds the next double quoted JSON string literal in s, returning the literal including its quotes and
+// the remainder of s after it
+func nextJSONString(s string) (literal string, rest string, ok bool) {
+	start := strings.IndexByte(s, '"')
+	if start < 0 {
+		return "", "", false
+	}
+
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return s[start : i+1], s[i+1:], true
+		}
+	}
+
+	return "", "", false
+}
@@ -2,36 +2,154 @@
 package github
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
 	"github.com/DataDog/ghinstallation"
 	"github.com/google/go-github/v32/github"
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
-	"net/http"
 )
 
-// Client holds logic to create a new GitHub client
+// Client holds logic to create GitHub clients authenticated as installations of the application. The private key is
+// parsed once, and one client is kept per installation so installation tokens are reused until they near expiry.
+// Requests hitting rate limits or transient server errors are retried.
+//
+// When a personal access token is set, every client authenticates with the token instead and no application is needed.
 type Client struct {
 	privateKeyPath string
 	appID          int
+	baseURL        string
+	uploadURL      string
+	token          string
+
+	appsTransport *ghinstallation.AppsTransport
+
+	mu            sync.Mutex
+	installations map[int]*installation
 }
 
-// NewClient creates a new instance of Client, taking in Client options and creating a GitHub client
-func NewClient(options ...ClientOption) *Client {
+// installation holds the client and token transport kept for one installation of the application
+type installation struct {
+	client    *github.Client
+	transport tokenSource
+}
+
+// tokenSource is a transport authenticating requests with a token it can also hand out
+type tokenSource interface {
+	http.RoundTripper
+	Token() (string, error)
+}
+
+// NewClient creates a new instance of Client, taking in Client options and parsing the application's private key
+func NewClient(options ...ClientOption) (*Client, error) {
 	zerolog.TimeFieldFormat = ""
 
-	c := Client{}
+	c := Client{installations: make(map[int]*installation)}
 	for _, option := range options {
 		option(&c)
 	}
-	return &c
+
+	if c.token != "" {
+		_, err := c.newGitHubClient(http.DefaultClient)
+		return &c, err
+	}
+
+	at, err := ghinstallation.NewAppsTransportKeyFromFile(newRetryTransport(http.DefaultTransport, 0), c.appID, c.privateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse private key from file: %s", err)
+	}
+	c.appsTransport = at
+
+	// Fail on bad GitHub Enterprise Server URLs right away rather than on the first event
+	if _, err := c.newGitHubClient(http.DefaultClient); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
 }
 
-// CreateClient instantiates a new GitHub client, handling verification of the application id and private key
-func (c *Client) CreateClient(installationID int) *github.Client {
-	itr, err := ghinstallation.NewKeyFromFile(http.DefaultTransport, c.appID, installationID, c.privateKeyPath)
+// CreateClient returns a GitHub client authenticated as the passed in installation, creating it on first use. It is
+// safe for concurrent use.
+func (c *Client) CreateClient(installationID int) (*github.Client, error) {
+	i, err := c.getInstallation(installationID)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to parse private key from file.")
+		return nil, err
 	}
+	return i.client, nil
+}
 
-	return github.NewClient(&http.Client{Transport: itr})
+// CreateAppClient returns a GitHub client authenticated as the application itself, used for endpoints about the
+// application's installations
+func (c *Client) CreateAppClient() (*github.Client, error) {
+	if c.token != "" {
+		return nil, errors.New("No application is used when authenticating with a personal access token")
+	}
+	return c.newGitHubClient(&http.Client{Transport: c.appsTransport})
+}
+
+// InstallationToken returns a token authenticating as the passed in installation, for use outside of the API client
+// such as git operations. It is safe for concurrent use.
+func (c *Client) InstallationToken(installationID int) (string, error) {
+	i, err := c.getInstallation(installationID)
+	if err != nil {
+		return "", err
+	}
+	return i.transport.Token()
+}
+
+func (c *Client) getInstallation(installationID int) (*installation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// There are no installations when authenticating with a personal access token, every client is the same
+	if c.token != "" {
+		installationID = 0
+	}
+
+	if i, ok := c.installations[installationID]; ok {
+		return i, nil
+	}
+
+	if c.token != "" {
+		tr := &tokenTransport{tr: newRetryTransport(http.DefaultTransport, 0), token: c.token}
+		client, err := c.newGitHubClient(&http.Client{Transport: tr})
+		if err != nil {
+			return nil, err
+		}
+
+		i := &installation{client: client, transport: tr}
+		c.installations[installationID] = i
+		return i, nil
+	}
+
+	itr := &installationTransport{
+		tr:             newRetryTransport(http.DefaultTransport, installationID),
+		appsTransport:  c.appsTransport,
+		installationID: installationID,
+	}
+	client, err := c.newGitHubClient(&http.Client{Transport: itr})
+	if err != nil {
+		return nil, err
+	}
+	// Installation tokens are minted from the same API the client talks to
+	itr.baseURL = client.BaseURL.String()
+
+	i := &installation{client: client, transport: itr}
+	c.installations[installationID] = i
+	return i, nil
+}
+
+// newGitHubClient creates a GitHub client for github.com, or for GitHub Enterprise Server if its URLs are set
+func (c *Client) newGitHubClient(httpClient *http.Client) (*github.Client, error) {
+	if c.baseURL == "" {
+		return github.NewClient(httpClient), nil
+	}
+
+	client, err := github.NewEnterpriseClient(c.baseURL, c.uploadURL, httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse GitHub Enterprise Server URLs: %s", err)
+	}
+	return client, nil
 }
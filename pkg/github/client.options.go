@@ -16,3 +16,24 @@ func WithAppID(appID int) ClientOption {
 		c.appID = appID
 	}
 }
+
+// WithBaseURL sets client's GitHub Enterprise Server API URL, e.x. https://github.example.com/api/v3/
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithUploadURL sets client's GitHub Enterprise Server upload URL, e.x. https://github.example.com/api/uploads/
+func WithUploadURL(uploadURL string) ClientOption {
+	return func(c *Client) {
+		c.uploadURL = uploadURL
+	}
+}
+
+// WithToken sets client's personal access token, used instead of the application's private key
+func WithToken(token string) ClientOption {
+	return func(c *Client) {
+		c.token = token
+	}
+}
@@ -0,0 +1,172 @@
+package github
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newFakeGHES starts a server faking the parts of the GitHub Enterprise Server API the client uses, recording the
+// paths of the requests it receives
+func newFakeGHES(t *testing.T, paths *[]string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/app/installations/42/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		*paths = append(*paths, r.URL.Path)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"token": "installation-token", "expires_at": "2100-01-01T00:00:00Z"}`)
+	})
+	mux.HandleFunc("/api/v3/repos/zendesk/term-check", func(w http.ResponseWriter, r *http.Request) {
+		*paths = append(*paths, r.URL.Path)
+		assert.Equal(t, "token installation-token", r.Header.Get("Authorization"))
+		fmt.Fprint(w, `{"name": "term-check"}`)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Unexpected request to %s", r.URL.Path)
+		http.NotFound(w, r)
+	})
+	return httptest.NewServer(mux)
+}
+
+// writePrivateKey writes a freshly generated private key to a file in dir, returning the file's path
+func writePrivateKey(t *testing.T, dir string) string {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "PRIVATE_KEY")
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := ioutil.WriteFile(path, pemKey, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCreateClientEnterprise(t *testing.T) {
+	dir, err := ioutil.TempDir("", "client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var paths []string
+	ghes := newFakeGHES(t, &paths)
+	defer ghes.Close()
+
+	c, err := NewClient(
+		WithAppID(1),
+		WithPrivateKeyPath(writePrivateKey(t, dir)),
+		WithBaseURL(ghes.URL+"/api/v3/"),
+		WithUploadURL(ghes.URL+"/api/uploads/"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ghc, err := c.CreateClient(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, ghes.URL+"/api/v3/", ghc.BaseURL.String())
+	assert.Equal(t, ghes.URL+"/api/uploads/", ghc.UploadURL.String())
+
+	repo, _, err := ghc.Repositories.Get(context.Background(), "zendesk", "term-check")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "term-check", repo.GetName())
+	}
+	assert.Equal(t, []string{"/api/v3/app/installations/42/access_tokens", "/api/v3/repos/zendesk/term-check"}, paths)
+}
+
+func TestCreateClientReusesToken(t *testing.T) {
+	dir, err := ioutil.TempDir("", "client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var paths []string
+	ghes := newFakeGHES(t, &paths)
+	defer ghes.Close()
+
+	c, err := NewClient(WithAppID(1), WithPrivateKeyPath(writePrivateKey(t, dir)), WithBaseURL(ghes.URL+"/api/v3/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		ghc, err := c.CreateClient(42)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _, err = ghc.Repositories.Get(context.Background(), "zendesk", "term-check")
+		assert.NoError(t, err)
+	}
+
+	expected := []string{
+		"/api/v3/app/installations/42/access_tokens",
+		"/api/v3/repos/zendesk/term-check",
+		"/api/v3/repos/zendesk/term-check",
+	}
+	assert.Equal(t, expected, paths)
+}
+
+func TestNewClientInvalidKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "PRIVATE_KEY")
+	if err := ioutil.WriteFile(path, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = NewClient(WithAppID(1), WithPrivateKeyPath(path))
+	assert.Error(t, err)
+}
+
+func TestCreateClientWithToken(t *testing.T) {
+	var paths []string
+	ghes := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		assert.Equal(t, "token personal-token", r.Header.Get("Authorization"))
+		fmt.Fprint(w, `{"name": "term-check"}`)
+	}))
+	defer ghes.Close()
+
+	c, err := NewClient(WithToken("personal-token"), WithBaseURL(ghes.URL+"/api/v3/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Installation IDs don't matter, there are no installations
+	for _, id := range []int{0, 42} {
+		ghc, err := c.CreateClient(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _, err = ghc.Repositories.Get(context.Background(), "zendesk", "term-check")
+		assert.NoError(t, err)
+	}
+
+	token, err := c.InstallationToken(42)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "personal-token", token)
+	}
+	assert.Equal(t, []string{"/api/v3/repos/zendesk/term-check", "/api/v3/repos/zendesk/term-check"}, paths)
+
+	_, err = c.CreateAppClient()
+	assert.Error(t, err)
+}
@@ -0,0 +1,49 @@
+package github
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/go-github/v32/github"
+)
+
+// UploadSARIF uploads a SARIF log to code scanning, as the results for a commit on a ref such as refs/pull/1/head. The
+// ID of the upload is returned. Used as the client version in use has no support for code scanning uploads.
+func UploadSARIF(ctx context.Context, client *github.Client, owner, repo, commitSHA, ref string, sarif []byte) (string, error) {
+	// The API expects the log gzipped, then base64 encoded
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	if _, err := w.Write(sarif); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := client.NewRequest("POST", fmt.Sprintf("repos/%s/%s/code-scanning/sarifs", owner, repo), map[string]string{
+		"commit_sha": commitSHA,
+		"ref":        ref,
+		"sarif":      base64.StdEncoding.EncodeToString(gz.Bytes()),
+		"tool_name":  "term-check",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var upload struct {
+		ID string `json:"id"`
+	}
+	_, err = client.Do(ctx, req, &upload)
+	// Uploads are processed asynchronously, the client reports the 202 response as an error holding the body
+	if accepted, ok := err.(*github.AcceptedError); ok {
+		err = json.Unmarshal(accepted.Raw, &upload)
+	}
+	if err != nil {
+		return "", fmt.Errorf("Failed to upload SARIF for %s: %s", commitSHA, err)
+	}
+	return upload.ID, nil
+}
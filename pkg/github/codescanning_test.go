@@ -0,0 +1,58 @@
+package github
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadSARIF(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/repos/zendesk/term-check/code-scanning/sarifs", r.URL.Path)
+
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "abc123", body["commit_sha"])
+		assert.Equal(t, "refs/pull/1/head", body["ref"])
+
+		gz, err := base64.StdEncoding.DecodeString(body["sarif"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		zr, err := gzip.NewReader(bytes.NewReader(gz))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sarif, err := ioutil.ReadAll(zr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, `{"version":"2.1.0"}`, string(sarif))
+
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprint(w, `{"id": "47177e22", "url": "https://api.github.com/repos/zendesk/term-check/code-scanning/sarifs/47177e22"}`)
+	}))
+	defer srv.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	id, err := UploadSARIF(context.Background(), client, "zendesk", "term-check", "abc123", "refs/pull/1/head", []byte(`{"version":"2.1.0"}`))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "47177e22", id)
+	}
+}
@@ -0,0 +1,208 @@
+package github
+
+import (
+	"encoding/json"
+
+	"github.com/google/go-github/v32/github"
+)
+
+// MergeGroupEvent is triggered when a merge queue needs checks run on a merge group. The Webhook event name is
+// "merge_group". go-github does not know this event, so the server parses it itself.
+type MergeGroupEvent struct {
+	Action       *string              `json:"action,omitempty"`
+	MergeGroup   *MergeGroup          `json:"merge_group,omitempty"`
+	Repo         *github.Repository   `json:"repository,omitempty"`
+	Sender       *github.User         `json:"sender,omitempty"`
+	Installation *github.Installation `json:"installation,omitempty"`
+}
+
+// MergeGroup is a group of pull requests merged together by a merge queue
+type MergeGroup struct {
+	HeadSHA *string `json:"head_sha,omitempty"`
+	HeadRef *string `json:"head_ref,omitempty"`
+	BaseSHA *string `json:"base_sha,omitempty"`
+	BaseRef *string `json:"base_ref,omitempty"`
+}
+
+// DiscussionEvent is triggered when a discussion is created or changed. The Webhook event name is "discussion".
+// go-github does not know this event, so the server parses it itself.
+type DiscussionEvent struct {
+	Action       *string              `json:"action,omitempty"`
+	Discussion   *Discussion          `json:"discussion,omitempty"`
+	Changes      *github.EditChange   `json:"changes,omitempty"`
+	Repo         *github.Repository   `json:"repository,omitempty"`
+	Sender       *github.User         `json:"sender,omitempty"`
+	Installation *github.Installation `json:"installation,omitempty"`
+}
+
+// Discussion is a discussion in a repository
+type Discussion struct {
+	NodeID *string      `json:"node_id,omitempty"`
+	Number *int         `json:"number,omitempty"`
+	Title  *string      `json:"title,omitempty"`
+	Body   *string      `json:"body,omitempty"`
+	User   *github.User `json:"user,omitempty"`
+}
+
+// GetAction returns the Action field if it's non-nil, zero value otherwise
+func (e *DiscussionEvent) GetAction() string {
+	if e == nil || e.Action == nil {
+		return ""
+	}
+	return *e.Action
+}
+
+// GetDiscussion returns the Discussion field
+func (e *DiscussionEvent) GetDiscussion() *Discussion {
+	if e == nil {
+		return nil
+	}
+	return e.Discussion
+}
+
+// GetChanges returns the Changes field
+func (e *DiscussionEvent) GetChanges() *github.EditChange {
+	if e == nil {
+		return nil
+	}
+	return e.Changes
+}
+
+// GetRepo returns the Repo field
+func (e *DiscussionEvent) GetRepo() *github.Repository {
+	if e == nil {
+		return nil
+	}
+	return e.Repo
+}
+
+// GetSender returns the Sender field
+func (e *DiscussionEvent) GetSender() *github.User {
+	if e == nil {
+		return nil
+	}
+	return e.Sender
+}
+
+// GetInstallation returns the Installation field
+func (e *DiscussionEvent) GetInstallation() *github.Installation {
+	if e == nil {
+		return nil
+	}
+	return e.Installation
+}
+
+// GetNodeID returns the NodeID field if it's non-nil, zero value otherwise
+func (d *Discussion) GetNodeID() string {
+	if d == nil || d.NodeID == nil {
+		return ""
+	}
+	return *d.NodeID
+}
+
+// GetNumber returns the Number field if it's non-nil, zero value otherwise
+func (d *Discussion) GetNumber() int {
+	if d == nil || d.Number == nil {
+		return 0
+	}
+	return *d.Number
+}
+
+// GetTitle returns the Title field if it's non-nil, zero value otherwise
+func (d *Discussion) GetTitle() string {
+	if d == nil || d.Title == nil {
+		return ""
+	}
+	return *d.Title
+}
+
+// GetBody returns the Body field if it's non-nil, zero value otherwise
+func (d *Discussion) GetBody() string {
+	if d == nil || d.Body == nil {
+		return ""
+	}
+	return *d.Body
+}
+
+// GetAction returns the Action field if it's non-nil, zero value otherwise
+func (e *MergeGroupEvent) GetAction() string {
+	if e == nil || e.Action == nil {
+		return ""
+	}
+	return *e.Action
+}
+
+// GetMergeGroup returns the MergeGroup field
+func (e *MergeGroupEvent) GetMergeGroup() *MergeGroup {
+	if e == nil {
+		return nil
+	}
+	return e.MergeGroup
+}
+
+// GetRepo returns the Repo field
+func (e *MergeGroupEvent) GetRepo() *github.Repository {
+	if e == nil {
+		return nil
+	}
+	return e.Repo
+}
+
+// GetInstallation returns the Installation field
+func (e *MergeGroupEvent) GetInstallation() *github.Installation {
+	if e == nil {
+		return nil
+	}
+	return e.Installation
+}
+
+// GetHeadSHA returns the HeadSHA field if it's non-nil, zero value otherwise
+func (m *MergeGroup) GetHeadSHA() string {
+	if m == nil || m.HeadSHA == nil {
+		return ""
+	}
+	return *m.HeadSHA
+}
+
+// GetHeadRef returns the HeadRef field if it's non-nil, zero value otherwise
+func (m *MergeGroup) GetHeadRef() string {
+	if m == nil || m.HeadRef == nil {
+		return ""
+	}
+	return *m.HeadRef
+}
+
+// GetBaseSHA returns the BaseSHA field if it's non-nil, zero value otherwise
+func (m *MergeGroup) GetBaseSHA() string {
+	if m == nil || m.BaseSHA == nil {
+		return ""
+	}
+	return *m.BaseSHA
+}
+
+// GetBaseRef returns the BaseRef field if it's non-nil, zero value otherwise
+func (m *MergeGroup) GetBaseRef() string {
+	if m == nil || m.BaseRef == nil {
+		return ""
+	}
+	return *m.BaseRef
+}
+
+// ParseWebHook parses the payload of a webhook into its event type, covering the events go-github does not know on
+// top of those it does
+func ParseWebHook(messageType string, payload []byte) (interface{}, error) {
+	var event interface{}
+	switch messageType {
+	case "merge_group":
+		event = &MergeGroupEvent{}
+	case "discussion":
+		event = &DiscussionEvent{}
+	default:
+		return github.ParseWebHook(messageType, payload)
+	}
+
+	if err := json.Unmarshal(payload, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
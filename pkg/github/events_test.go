@@ -0,0 +1,32 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWebHook(t *testing.T) {
+	payload := []byte(`{
+		"action": "checks_requested",
+		"merge_group": {"head_sha": "abc", "head_ref": "refs/heads/gh-readonly-queue/main/pr-1-def", "base_sha": "def"},
+		"repository": {"name": "term-check"},
+		"installation": {"id": 42}
+	}`)
+
+	event, err := ParseWebHook("merge_group", payload)
+	if assert.NoError(t, err) && assert.IsType(t, &MergeGroupEvent{}, event) {
+		e := event.(*MergeGroupEvent)
+		assert.Equal(t, "checks_requested", e.GetAction())
+		assert.Equal(t, "abc", e.GetMergeGroup().GetHeadSHA())
+		assert.Equal(t, "def", e.GetMergeGroup().GetBaseSHA())
+		assert.Equal(t, "term-check", e.GetRepo().GetName())
+		assert.Equal(t, int64(42), e.GetInstallation().GetID())
+	}
+
+	event, err = ParseWebHook("pull_request", []byte(`{"action": "opened"}`))
+	if assert.NoError(t, err) {
+		assert.IsType(t, &github.PullRequestEvent{}, event)
+	}
+}
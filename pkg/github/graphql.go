@@ -0,0 +1,44 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/go-github/v32/github"
+)
+
+// graphQLPath is the GraphQL endpoint relative to the REST API base URL. It resolves to /graphql on github.com and to
+// /api/graphql on GitHub Enterprise Server, whose REST API lives under /api/v3/.
+const graphQLPath = "../graphql"
+
+// GraphQL runs a GraphQL query or mutation with the passed in client, decoding the response's data into data. Used for
+// the parts of the API only available through GraphQL, such as discussions.
+func GraphQL(ctx context.Context, client *github.Client, query string, variables map[string]interface{}, data interface{}) error {
+	req, err := client.NewRequest("POST", graphQLPath, map[string]interface{}{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if _, err := client.Do(ctx, req, &resp); err != nil {
+		return err
+	}
+
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("GraphQL request failed: %s", resp.Errors[0].Message)
+	}
+	if len(resp.Data) == 0 {
+		return errors.New("GraphQL request returned no data")
+	}
+	return json.Unmarshal(resp.Data, data)
+}
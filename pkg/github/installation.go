@@ -0,0 +1,125 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/DataDog/ghinstallation"
+)
+
+// tokenRefreshWindow is how long before expiry an installation token is replaced
+const tokenRefreshWindow = 5 * time.Minute
+
+// tokenRequestTimeout bounds how long minting an installation token may take
+const tokenRequestTimeout = 30 * time.Second
+
+// installationTransport authenticates requests as an installation of the application. Installation tokens are minted
+// with the application's already parsed key, and kept until they near expiry. Concurrent requests needing a new token
+// share one request for it.
+type installationTransport struct {
+	tr             http.RoundTripper
+	appsTransport  *ghinstallation.AppsTransport
+	baseURL        string
+	installationID int
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+	refresh   *tokenRefresh
+}
+
+// tokenRefresh is a request for a new installation token, which callers needing a token wait on until done is closed
+type tokenRefresh struct {
+	done      chan struct{}
+	token     string
+	expiresAt time.Time
+	err       error
+}
+
+// RoundTrip implements http.RoundTripper, adding the installation token to the request
+func (t *installationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.tokenWithContext(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "token "+token)
+	return t.tr.RoundTrip(req)
+}
+
+// Token returns a valid installation token, refreshing it first if it is missing or about to expire
+func (t *installationTransport) Token() (string, error) {
+	return t.tokenWithContext(context.Background())
+}
+
+// tokenWithContext returns a valid installation token, refreshing it first if it is missing or about to expire. The
+// first caller needing a new token requests it, others wait for that request unless their context is done first.
+func (t *installationTransport) tokenWithContext(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	if t.token != "" && time.Now().Add(tokenRefreshWindow).Before(t.expiresAt) {
+		defer t.mu.Unlock()
+		return t.token, nil
+	}
+
+	if r := t.refresh; r != nil {
+		t.mu.Unlock()
+		select {
+		case <-r.done:
+			return r.token, r.err
+		case <-ctx.Done():
+			return "", fmt.Errorf("Failed to get token for installation %d: %s", t.installationID, ctx.Err())
+		}
+	}
+
+	r := &tokenRefresh{done: make(chan struct{})}
+	t.refresh = r
+	t.mu.Unlock()
+
+	r.token, r.expiresAt, r.err = t.requestToken(ctx)
+
+	t.mu.Lock()
+	if r.err == nil {
+		t.token, t.expiresAt = r.token, r.expiresAt
+	}
+	t.refresh = nil
+	t.mu.Unlock()
+	close(r.done)
+
+	return r.token, r.err
+}
+
+// requestToken mints a new installation token
+func (t *installationTransport) requestToken(ctx context.Context) (string, time.Time, error) {
+	ctx, cancel := context.WithTimeout(ctx, tokenRequestTimeout)
+	defer cancel()
+
+	url := fmt.Sprintf("%sapp/installations/%d/access_tokens", t.baseURL, t.installationID)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	resp, err := t.appsTransport.RoundTrip(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("Failed to get token for installation %d: %s", t.installationID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return "", time.Time{}, fmt.Errorf("Failed to get token for installation %d: %s", t.installationID, resp.Status)
+	}
+
+	var body struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", time.Time{}, fmt.Errorf("Failed to decode token for installation %d: %s", t.installationID, err)
+	}
+
+	return body.Token, body.ExpiresAt, nil
+}
@@ -0,0 +1,101 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DataDog/ghinstallation"
+	"github.com/stretchr/testify/assert"
+)
+
+// newInstallationTransport creates a transport minting tokens for installation 42 from the server at baseURL
+func newInstallationTransport(t *testing.T, baseURL string) *installationTransport {
+	dir, err := ioutil.TempDir("", "installation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	at, err := ghinstallation.NewAppsTransportKeyFromFile(http.DefaultTransport, 1, writePrivateKey(t, dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &installationTransport{appsTransport: at, baseURL: baseURL, installationID: 42}
+}
+
+func TestTokenSharesOneRequest(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"token": "installation-token", "expires_at": "2100-01-01T00:00:00Z"}`)
+	}))
+	defer server.Close()
+
+	tr := newInstallationTransport(t, server.URL+"/")
+
+	var wg sync.WaitGroup
+	tokens := make([]string, 5)
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			token, err := tr.Token()
+			assert.NoError(t, err)
+			tokens[i] = token
+		}(i)
+	}
+
+	// A caller giving up stops waiting without holding up, or being held up by, the others
+	for atomic.LoadInt32(&requests) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := tr.tokenWithContext(ctx)
+	assert.Error(t, err)
+
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	for _, token := range tokens {
+		assert.Equal(t, "installation-token", token)
+	}
+}
+
+func TestTokenRequestIsCancelled(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"token": "installation-token", "expires_at": "2100-01-01T00:00:00Z"}`)
+	}))
+	defer server.Close()
+
+	tr := newInstallationTransport(t, server.URL+"/")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := tr.tokenWithContext(ctx)
+	assert.Error(t, err)
+
+	// The failed request is not kept, the next caller requests a token again
+	token, err := tr.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, "installation-token", token)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
@@ -0,0 +1,187 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Defaults for the queue of events waiting to be handled
+const (
+	defaultWorkers    = 4
+	defaultQueueDepth = 100
+	defaultJobTimeout = 5 * time.Minute
+)
+
+// cancelWait is how long workers get to return once in-flight events are cancelled at the end of a shutdown
+const cancelWait = 5 * time.Second
+
+// queueHighWatermark is the share of the queue depth past which the queue is reported as backing up
+const queueHighWatermark = 0.8
+
+var (
+	// errQueueFull is returned when an event is rejected because the queue is at its depth limit
+	errQueueFull = errors.New("Event queue is full")
+	// errQueueStopped is returned when an event is rejected because the server is shutting down
+	errQueueStopped = errors.New("Event queue is stopped")
+)
+
+// job is an event waiting in the queue to be handled, along with the logger for its delivery
+type job struct {
+	event    interface{}
+	logger   *zerolog.Logger
+	queuedAt time.Time
+}
+
+// queue hands events to a fixed number of workers, so webhook requests can be answered before the events are handled.
+// Events are rejected rather than waited for once the queue is full.
+type queue struct {
+	handler EventHandler
+	workers int
+	timeout time.Duration
+	jobs    chan job
+	wg      sync.WaitGroup
+
+	// ctx is the parent of every job's context, cancelled when stopping takes longer than allowed
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	stopped bool
+}
+
+func newQueue(handler EventHandler, workers, depth int, timeout time.Duration) *queue {
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	if depth <= 0 {
+		depth = defaultQueueDepth
+	}
+	if timeout <= 0 {
+		timeout = defaultJobTimeout
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &queue{
+		handler: handler,
+		workers: workers,
+		timeout: timeout,
+		jobs:    make(chan job, depth),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// start starts the workers, which run until the queue is closed
+func (q *queue) start() {
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+	log.Info().Msgf("Started %d worker(s) with a queue depth of %d", q.workers, cap(q.jobs))
+}
+
+// stop stops accepting events and waits for the queued and in-flight events to be handled. Once ctx is done, in-flight
+// events are cancelled and queued ones dropped.
+func (q *queue) stop(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.stopped {
+		q.stopped = true
+		close(q.jobs)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	q.cancel()
+	log.Warn().Int("Dropped", len(q.jobs)).Msg("Cancelled in-flight events that did not finish in time")
+	select {
+	case <-done:
+	case <-time.After(cancelWait):
+	}
+	return fmt.Errorf("Failed to drain event queue: %s", ctx.Err())
+}
+
+// enqueue adds an event to the queue without waiting, failing if the queue is full or stopped. The logger in ctx is
+// passed on to the handler, ctx is not used otherwise.
+func (q *queue) enqueue(ctx context.Context, event interface{}) error {
+	logger := log.Ctx(ctx)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.stopped {
+		return errQueueStopped
+	}
+
+	select {
+	case q.jobs <- job{event: event, logger: logger, queuedAt: time.Now()}:
+	default:
+		logger.Error().Int("Depth", cap(q.jobs)).Msgf("Event queue is full, rejecting %s", eventName(event))
+		return errQueueFull
+	}
+
+	if depth := len(q.jobs); float64(depth) >= queueHighWatermark*float64(cap(q.jobs)) {
+		logger.Warn().Int("Queued", depth).Int("Depth", cap(q.jobs)).Msg("Event queue is backing up")
+	}
+	return nil
+}
+
+func (q *queue) work() {
+	defer q.wg.Done()
+
+	for j := range q.jobs {
+		if q.ctx.Err() != nil {
+			continue
+		}
+		q.handle(j)
+	}
+}
+
+// handle passes a job's event to the handler with the job timeout, keeping the worker alive if the handler panics
+func (q *queue) handle(j job) {
+	name := eventName(j.event)
+	j.logger.Debug().Dur("Waited", time.Since(j.queuedAt)).Msgf("Handling %s", name)
+
+	ctx, cancel := context.WithTimeout(j.logger.WithContext(q.ctx), q.timeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			j.logger.Error().Interface("Panic", r).Msgf("Panicked while handling %s", name)
+		}
+	}()
+
+	q.handler.HandleEvent(ctx, j.event)
+
+	if ctx.Err() == context.DeadlineExceeded {
+		j.logger.Error().Dur("Timeout", q.timeout).Msgf("Timed out while handling %s", name)
+	}
+}
+
+func eventName(event interface{}) string {
+	t := reflect.TypeOf(event)
+	if t == nil {
+		return "<nil>"
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
@@ -0,0 +1,106 @@
+package github
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// handlerFunc adapts a function to the EventHandler interface
+type handlerFunc func(ctx context.Context, event interface{})
+
+func (f handlerFunc) HandleEvent(ctx context.Context, event interface{}) {
+	f(ctx, event)
+}
+
+func TestQueueRejectsWhenFull(t *testing.T) {
+	q := newQueue(handlerFunc(func(context.Context, interface{}) {}), 1, 2, time.Second)
+
+	// Workers are not started, so nothing leaves the queue
+	assert.NoError(t, q.enqueue(context.Background(), "first"))
+	assert.NoError(t, q.enqueue(context.Background(), "second"))
+	assert.Equal(t, errQueueFull, q.enqueue(context.Background(), "third"))
+}
+
+func TestQueueHandlesEvents(t *testing.T) {
+	handled := make(chan interface{}, 2)
+	q := newQueue(handlerFunc(func(ctx context.Context, event interface{}) {
+		if event == "panic" {
+			panic("handler failed")
+		}
+		_, hasDeadline := ctx.Deadline()
+		assert.True(t, hasDeadline)
+		handled <- event
+	}), 1, 10, time.Second)
+	q.start()
+
+	// A panicking handler must not take its worker down
+	assert.NoError(t, q.enqueue(context.Background(), "panic"))
+	assert.NoError(t, q.enqueue(context.Background(), "event"))
+
+	select {
+	case event := <-handled:
+		assert.Equal(t, "event", event)
+	case <-time.After(time.Second):
+		t.Fatal("Event was not handled")
+	}
+}
+
+func TestQueueTimesOutJobs(t *testing.T) {
+	done := make(chan error, 1)
+	q := newQueue(handlerFunc(func(ctx context.Context, event interface{}) {
+		<-ctx.Done()
+		done <- ctx.Err()
+	}), 1, 1, 10*time.Millisecond)
+	q.start()
+
+	assert.NoError(t, q.enqueue(context.Background(), "slow"))
+
+	select {
+	case err := <-done:
+		assert.Equal(t, context.DeadlineExceeded, err)
+	case <-time.After(time.Second):
+		t.Fatal("Job did not time out")
+	}
+}
+
+func TestQueueStopDrainsEvents(t *testing.T) {
+	var handled []interface{}
+	q := newQueue(handlerFunc(func(ctx context.Context, event interface{}) {
+		time.Sleep(10 * time.Millisecond)
+		handled = append(handled, event)
+	}), 1, 10, time.Second)
+	q.start()
+
+	assert.NoError(t, q.enqueue(context.Background(), "first"))
+	assert.NoError(t, q.enqueue(context.Background(), "second"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, q.stop(ctx))
+	assert.Equal(t, []interface{}{"first", "second"}, handled)
+
+	assert.Equal(t, errQueueStopped, q.enqueue(context.Background(), "third"))
+}
+
+func TestQueueStopCancelsAfterGracePeriod(t *testing.T) {
+	cancelled := make(chan error, 1)
+	q := newQueue(handlerFunc(func(ctx context.Context, event interface{}) {
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+	}), 1, 10, time.Minute)
+	q.start()
+
+	assert.NoError(t, q.enqueue(context.Background(), "stuck"))
+	assert.NoError(t, q.enqueue(context.Background(), "dropped"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Error(t, q.stop(ctx))
+
+	assert.Equal(t, context.Canceled, <-cancelled)
+	// The queued event is dropped rather than handled with a cancelled context
+	assert.Empty(t, cancelled)
+}
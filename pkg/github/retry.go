@@ -0,0 +1,161 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zendesk/term-check/pkg/lib"
+)
+
+const (
+	defaultMaxRetries = 3
+	defaultBaseDelay  = time.Second
+	// defaultMaxWait caps how long a single retry waits. Rate limits resetting later than that are returned to the
+	// caller instead of holding up the event.
+	defaultMaxWait = time.Minute
+	// lowQuotaRatio is the share of the rate limit left under which remaining quota is logged as a warning
+	lowQuotaRatio = 0.1
+)
+
+// idempotentMethods are the methods retried on transient failures. A write can fail after GitHub has applied it, so
+// retrying any other method could e.x. create a check run or comment twice.
+var idempotentMethods = map[string]struct{}{
+	http.MethodGet:    {},
+	http.MethodHead:   {},
+	http.MethodPut:    {},
+	http.MethodDelete: {},
+}
+
+// retryTransport retries requests that hit rate limits or transient server errors. Rate limited requests wait for
+// the time given by the Retry-After or X-RateLimit-Reset headers, other failures back off exponentially. Only rate
+// limited requests are retried for methods that are not idempotent, as GitHub does not apply those. The remaining
+// quota of every response is logged for the installation the transport belongs to.
+type retryTransport struct {
+	tr             http.RoundTripper
+	installationID int
+	maxRetries     int
+	baseDelay      time.Duration
+	maxWait        time.Duration
+	sleep          func(ctx context.Context, d time.Duration) error
+}
+
+// newRetryTransport creates a retryTransport with default settings around tr
+func newRetryTransport(tr http.RoundTripper, installationID int) *retryTransport {
+	return &retryTransport{
+		tr:             tr,
+		installationID: installationID,
+		maxRetries:     defaultMaxRetries,
+		baseDelay:      defaultBaseDelay,
+		maxWait:        defaultMaxWait,
+		sleep:          sleep,
+	}
+}
+
+// RoundTrip implements http.RoundTripper, retrying the request while it fails with a retryable error
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.Body != nil {
+			// Bodies are consumed by the previous attempt, so they need to be recreated
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		resp, err := t.tr.RoundTrip(req)
+		if err == nil {
+			t.logQuota(resp)
+		}
+
+		wait, retry := t.retryAfter(req, resp, err, attempt)
+		if !retry || attempt >= t.maxRetries || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+
+		log.Ctx(req.Context()).Warn().Int("Installation", t.installationID).Msgf("Retrying %s %s in %s: %s", req.Method, req.URL.Path, wait, describe(resp, err))
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if err := t.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryAfter returns how long to wait before retrying a request that got the passed in response or error, and whether
+// it should be retried at all
+func (t *retryTransport) retryAfter(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	backoff := t.baseDelay << uint(attempt)
+	idempotent := lib.Contains(idempotentMethods, req.Method)
+
+	if err != nil {
+		return backoff, idempotent
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests:
+		// Abuse and secondary rate limits say how long to wait
+		if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			wait := time.Duration(s) * time.Second
+			return wait, wait <= t.maxWait
+		}
+		// Primary rate limits say when the quota resets
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+			if err != nil {
+				return 0, false
+			}
+			wait := time.Until(time.Unix(reset, 0))
+			if wait < 0 {
+				wait = 0
+			}
+			return wait, wait <= t.maxWait
+		}
+		return 0, false
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return backoff, idempotent
+	default:
+		return 0, false
+	}
+}
+
+// logQuota logs the rate limit quota left according to a response
+func (t *retryTransport) logQuota(resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	limit, _ := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+
+	logger := log.Ctx(resp.Request.Context())
+	e := logger.Debug()
+	if float64(remaining) < float64(limit)*lowQuotaRatio {
+		e = logger.Warn()
+	}
+	e.Int("Installation", t.installationID).Int("Remaining", remaining).Int("Limit", limit).Msg("GitHub API quota")
+}
+
+func describe(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("received %s", resp.Status)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,113 @@
+package github
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type retryTestCase struct {
+	name           string
+	method         string
+	responses      []func(w http.ResponseWriter)
+	expectedStatus int
+	expectedWaits  []time.Duration
+}
+
+func TestRetryTransport(t *testing.T) {
+	ok := func(w http.ResponseWriter) { w.WriteHeader(http.StatusOK) }
+	unavailable := func(w http.ResponseWriter) { w.WriteHeader(http.StatusServiceUnavailable) }
+	abuse := func(w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusForbidden)
+	}
+	forbidden := func(w http.ResponseWriter) { w.WriteHeader(http.StatusForbidden) }
+	longAbuse := func(w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusForbidden)
+	}
+
+	cases := []retryTestCase{
+		{
+			name:           "Success",
+			method:         "POST",
+			responses:      []func(w http.ResponseWriter){ok},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "BacksOffOnServerErrors",
+			method:         "PUT",
+			responses:      []func(w http.ResponseWriter){unavailable, unavailable, ok},
+			expectedStatus: http.StatusOK,
+			expectedWaits:  []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:           "GivesUpAfterMaxRetries",
+			method:         "PUT",
+			responses:      []func(w http.ResponseWriter){unavailable, unavailable, unavailable, unavailable},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedWaits:  []time.Duration{time.Second, 2 * time.Second, 4 * time.Second},
+		},
+		{
+			name:           "DoesNotRetryWritesOnServerErrors",
+			method:         "POST",
+			responses:      []func(w http.ResponseWriter){unavailable},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "HonorsRetryAfter",
+			method:         "POST",
+			responses:      []func(w http.ResponseWriter){abuse, ok},
+			expectedStatus: http.StatusOK,
+			expectedWaits:  []time.Duration{7 * time.Second},
+		},
+		{
+			name:           "DoesNotRetryLongWaits",
+			method:         "POST",
+			responses:      []func(w http.ResponseWriter){longAbuse},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "DoesNotRetryForbidden",
+			method:         "POST",
+			responses:      []func(w http.ResponseWriter){forbidden},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				assert.Equal(t, "payload", string(body))
+
+				tc.responses[calls](w)
+				calls++
+			}))
+			defer server.Close()
+
+			var waits []time.Duration
+			rt := newRetryTransport(http.DefaultTransport, 1)
+			rt.sleep = func(ctx context.Context, d time.Duration) error {
+				waits = append(waits, d)
+				return nil
+			}
+
+			req, _ := http.NewRequest(tc.method, server.URL, strings.NewReader("payload"))
+			resp, err := rt.RoundTrip(req)
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+				assert.Equal(t, tc.expectedStatus, resp.StatusCode)
+			}
+			assert.Equal(t, tc.expectedWaits, waits)
+			assert.Equal(t, len(tc.responses), calls)
+		})
+	}
+}
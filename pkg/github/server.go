@@ -1,24 +1,67 @@
 package github
 
 import (
+	"context"
+	"crypto/tls"
+	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/google/go-github/v32/github"
 	"github.com/gorilla/mux"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"github.com/zendesk/term-check/pkg/lib"
 )
 
-// EventHandler interface to allow hooking into GitHub events
+// EventHandler interface to allow hooking into GitHub events. The context is cancelled once the event's job times out
 type EventHandler interface {
-	HandleEvent(event interface{})
+	HandleEvent(ctx context.Context, event interface{})
 }
 
-// Server used to listen for and pass off GitHub events
+// Server used to listen for and pass off GitHub events. Events are queued and handled by a pool of workers, so GitHub
+// gets a response before the event is handled.
 type Server struct {
-	webhookSecretKey string
-	eventHandler     EventHandler
+	webhookSecretKey    string
+	eventHandler        EventHandler
+	handlers            []handler
+	workers             int
+	queueDepth          int
+	jobTimeout          time.Duration
+	shutdownGracePeriod time.Duration
+	queue               *queue
+	address             string
+	readTimeout         time.Duration
+	writeTimeout        time.Duration
+	idleTimeout         time.Duration
+	webhookPath         string
+	tlsCert             []byte
+	tlsKey              []byte
+	dedupTTL            time.Duration
+	deliveries          *lib.TTLSet
+}
+
+// Defaults for the HTTP server
+const (
+	defaultAddress      = "0.0.0.0:8080"
+	defaultReadTimeout  = 15 * time.Second
+	defaultWriteTimeout = 15 * time.Second
+	defaultIdleTimeout  = 60 * time.Second
+	defaultWebhookPath  = "/"
+	// defaultDedupTTL is how long delivery IDs are remembered to discard redeliveries
+	defaultDedupTTL = time.Hour
+	// defaultShutdownGracePeriod leaves time to exit before Kubernetes' default termination grace period of 30s runs out
+	defaultShutdownGracePeriod = 25 * time.Second
+)
+
+// handler is an additional HTTP endpoint served next to the webhook endpoint
+type handler struct {
+	path    string
+	method  string
+	handler http.HandlerFunc
 }
 
 // NewServer creates a new instance of Server, taking in ServerOptions
@@ -29,6 +72,8 @@ func NewServer(options ...ServerOption) *Server {
 	for _, option := range options {
 		option(&s)
 	}
+	s.queue = newQueue(s.eventHandler, s.workers, s.queueDepth, s.jobTimeout)
+	s.deliveries = lib.NewTTLSet(durationOrDefault(s.dedupTTL, defaultDedupTTL))
 	return &s
 }
 
@@ -36,38 +81,135 @@ func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
 	var err error
 	var event interface{}
 
+	delivery := github.DeliveryID(r)
+	logger := log.With().Str("Delivery", delivery).Logger()
+
 	payload, err := github.ValidatePayload(r, []byte(s.webhookSecretKey))
 	if err == nil {
-		event, err = github.ParseWebHook(github.WebHookType(r), payload)
+		event, err = ParseWebHook(github.WebHookType(r), payload)
 	}
 
 	if err != nil {
-		log.Error().Err(err).Msg("Error handling incoming GitHub event")
+		logger.Error().Err(err).Msg("Error handling incoming GitHub event")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	s.eventHandler.HandleEvent(event)
-	w.WriteHeader(http.StatusOK)
+	// Redeliveries reuse the ID of the original delivery
+	if delivery != "" && !s.deliveries.Add(delivery) {
+		logger.Info().Msg("Duplicate delivery received. Discarding...")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Rejected deliveries can be redelivered from the app's settings once the queue catches up
+	if err := s.queue.enqueue(logger.WithContext(r.Context()), event); err != nil {
+		s.deliveries.Remove(delivery)
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Enqueue queues an event that did not come from a webhook to be handled by the workers, e.x. work started on demand.
+// The logger in ctx is used for the event, ctx is not used otherwise.
+func (s *Server) Enqueue(ctx context.Context, event interface{}) error {
+	return s.queue.enqueue(ctx, event)
 }
 
 func (s *Server) healthCheck(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-// Start starts the server
-func (s *Server) Start() {
+// Start starts the server, and blocks until it is shut down by SIGINT or SIGTERM. On shutdown, new webhooks are
+// refused while queued and in-flight events get the grace period to finish.
+func (s *Server) Start() error {
+	webhookPath := orDefault(s.webhookPath, defaultWebhookPath)
+
 	r := mux.NewRouter()
-	r.HandleFunc("/", s.handleEvents).Methods("POST")
+	r.HandleFunc(webhookPath, s.handleEvents).Methods("POST")
 	r.HandleFunc("/", s.healthCheck).Methods("GET")
+	if webhookPath != "/" {
+		r.HandleFunc(webhookPath, s.healthCheck).Methods("GET")
+	}
+	for _, h := range s.handlers {
+		r.HandleFunc(h.path, h.handler).Methods(h.method)
+	}
 
 	srv := &http.Server{
-		Addr:         "0.0.0.0:8080",
+		Addr:         orDefault(s.address, defaultAddress),
 		Handler:      r,
-		IdleTimeout:  time.Second * 60,
-		ReadTimeout:  time.Second * 15,
-		WriteTimeout: time.Second * 15,
+		IdleTimeout:  durationOrDefault(s.idleTimeout, defaultIdleTimeout),
+		ReadTimeout:  durationOrDefault(s.readTimeout, defaultReadTimeout),
+		WriteTimeout: durationOrDefault(s.writeTimeout, defaultWriteTimeout),
+	}
+
+	tlsEnabled := len(s.tlsCert) > 0 || len(s.tlsKey) > 0
+	if tlsEnabled {
+		cert, err := tls.X509KeyPair(s.tlsCert, s.tlsKey)
+		if err != nil {
+			return fmt.Errorf("Failed to load TLS certificate: %s", err)
+		}
+		srv.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
 	}
 
-	log.Fatal().Err(srv.ListenAndServe())
+	s.queue.start()
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Info().Bool("TLS", tlsEnabled).Msgf("Listening on %s, receiving webhooks on %s", srv.Addr, webhookPath)
+		if tlsEnabled {
+			// The certificate is already in TLSConfig
+			errs <- srv.ListenAndServeTLS("", "")
+			return
+		}
+		errs <- srv.ListenAndServe()
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	select {
+	case err := <-errs:
+		return fmt.Errorf("Failed to serve: %s", err)
+	case sig := <-signals:
+		log.Info().Msgf("Received %s, shutting down...", sig)
+	}
+
+	return s.shutdown(srv)
+}
+
+// shutdown stops the HTTP server, then drains the queue, both within the grace period
+func (s *Server) shutdown(srv *http.Server) error {
+	gracePeriod := s.shutdownGracePeriod
+	if gracePeriod <= 0 {
+		gracePeriod = defaultShutdownGracePeriod
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		return fmt.Errorf("Failed to shut down HTTP server: %s", err)
+	}
+	if err := s.queue.stop(ctx); err != nil {
+		return err
+	}
+
+	log.Info().Msg("Shut down")
+	return nil
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func durationOrDefault(value, fallback time.Duration) time.Duration {
+	if value <= 0 {
+		return fallback
+	}
+	return value
 }
@@ -1,5 +1,10 @@
 package github
 
+import (
+	"net/http"
+	"time"
+)
+
 // ServerOption an option function to customize Server
 type ServerOption func(*Server)
 
@@ -16,3 +21,76 @@ func WithEventHandler(eventHandler EventHandler) ServerOption {
 		s.eventHandler = eventHandler
 	}
 }
+
+// WithHandler adds an HTTP endpoint to Server. The path follows gorilla/mux route templates, e.x. /audits/{owner}
+func WithHandler(method, path string, h http.HandlerFunc) ServerOption {
+	return func(s *Server) {
+		s.handlers = append(s.handlers, handler{path: path, method: method, handler: h})
+	}
+}
+
+// WithWorkers sets the number of Server's workers handling events
+func WithWorkers(workers int) ServerOption {
+	return func(s *Server) {
+		s.workers = workers
+	}
+}
+
+// WithQueueDepth sets how many events can wait for a worker before Server rejects new ones
+func WithQueueDepth(queueDepth int) ServerOption {
+	return func(s *Server) {
+		s.queueDepth = queueDepth
+	}
+}
+
+// WithJobTimeout sets how long Server's workers spend on an event before its context is cancelled
+func WithJobTimeout(jobTimeout time.Duration) ServerOption {
+	return func(s *Server) {
+		s.jobTimeout = jobTimeout
+	}
+}
+
+// WithShutdownGracePeriod sets how long Server waits for queued and in-flight events when shutting down
+func WithShutdownGracePeriod(shutdownGracePeriod time.Duration) ServerOption {
+	return func(s *Server) {
+		s.shutdownGracePeriod = shutdownGracePeriod
+	}
+}
+
+// WithAddress sets the host and port Server listens on, e.x. 0.0.0.0:8080
+func WithAddress(address string) ServerOption {
+	return func(s *Server) {
+		s.address = address
+	}
+}
+
+// WithTimeouts sets Server's HTTP read, write and idle timeouts. Zero values keep the defaults
+func WithTimeouts(read, write, idle time.Duration) ServerOption {
+	return func(s *Server) {
+		s.readTimeout = read
+		s.writeTimeout = write
+		s.idleTimeout = idle
+	}
+}
+
+// WithWebhookPath sets the path Server receives webhooks on
+func WithWebhookPath(webhookPath string) ServerOption {
+	return func(s *Server) {
+		s.webhookPath = webhookPath
+	}
+}
+
+// WithTLS sets the PEM encoded certificate and key Server serves HTTPS with. Server serves plain HTTP when unset
+func WithTLS(cert, key []byte) ServerOption {
+	return func(s *Server) {
+		s.tlsCert = cert
+		s.tlsKey = key
+	}
+}
+
+// WithDedupTTL sets how long Server remembers delivery IDs to discard redeliveries of the same webhook
+func WithDedupTTL(dedupTTL time.Duration) ServerOption {
+	return func(s *Server) {
+		s.dedupTTL = dedupTTL
+	}
+}
@@ -0,0 +1,48 @@
+package github
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartInvalidTLS(t *testing.T) {
+	s := NewServer(WithAddress("127.0.0.1:0"), WithTLS([]byte("not a certificate"), []byte("not a key")))
+
+	err := s.Start()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Failed to load TLS certificate")
+	}
+}
+
+func TestHandleEventsDuplicateDelivery(t *testing.T) {
+	secret := "secret"
+	payload := `{"action":"opened","pull_request":{"number":1}}`
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write([]byte(payload))
+
+	s := NewServer(WithWebhookSecretKey(secret), WithEventHandler(handlerFunc(func(context.Context, interface{}) {})))
+
+	deliver := func(delivery string) int {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-GitHub-Event", "pull_request")
+		req.Header.Set("X-GitHub-Delivery", delivery)
+		req.Header.Set("X-Hub-Signature", "sha1="+hex.EncodeToString(mac.Sum(nil)))
+		w := httptest.NewRecorder()
+		s.handleEvents(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusAccepted, deliver("a"))
+	assert.Equal(t, http.StatusOK, deliver("a"))
+	assert.Equal(t, http.StatusAccepted, deliver("b"))
+	assert.Equal(t, 2, len(s.queue.jobs))
+}
@@ -0,0 +1,22 @@
+package github
+
+import "net/http"
+
+// tokenTransport authenticates requests with a personal access token, for deployments that cannot register an
+// application
+type tokenTransport struct {
+	tr    http.RoundTripper
+	token string
+}
+
+// RoundTrip implements http.RoundTripper, adding the token to a copy of the request
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "token "+t.token)
+	return t.tr.RoundTrip(req)
+}
+
+// Token returns the personal access token
+func (t *tokenTransport) Token() (string, error) {
+	return t.token, nil
+}
@@ -0,0 +1,48 @@
+package lib
+
+import (
+	"sync"
+	"time"
+)
+
+// TTLSet is a set of strings that forgets each item once its time to live has passed. It is safe for concurrent use.
+type TTLSet struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu    sync.Mutex
+	items map[string]time.Time
+}
+
+// NewTTLSet returns an empty TTLSet keeping items for ttl
+func NewTTLSet(ttl time.Duration) *TTLSet {
+	return &TTLSet{ttl: ttl, now: time.Now, items: make(map[string]time.Time)}
+}
+
+// Add adds an item to the set, returning false if it was already present
+func (s *TTLSet) Add(item string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	// Expired items are swept on every add, which keeps the set bounded without a background goroutine
+	for i, expiresAt := range s.items {
+		if !now.Before(expiresAt) {
+			delete(s.items, i)
+		}
+	}
+
+	if _, ok := s.items[item]; ok {
+		return false
+	}
+	s.items[item] = now.Add(s.ttl)
+	return true
+}
+
+// Remove removes an item from the set, so it can be added again before its time to live has passed
+func (s *TTLSet) Remove(item string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.items, item)
+}
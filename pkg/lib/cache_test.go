@@ -0,0 +1,24 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTTLSet(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := NewTTLSet(time.Minute)
+	s.now = func() time.Time { return now }
+
+	assert.True(t, s.Add("a"))
+	assert.False(t, s.Add("a"))
+	assert.True(t, s.Add("b"))
+
+	now = now.Add(time.Minute)
+	assert.True(t, s.Add("a"), "expired items can be added again")
+
+	s.Remove("a")
+	assert.True(t, s.Add("a"), "removed items can be added again")
+}
@@ -1,6 +1,12 @@
 // Package lib contains generic helper functions for massaging and handling data
 package lib
 
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
 // Contains returns a boolean indicating whether a string is present in a passed in set of strings
 func Contains(set map[string]struct{}, item string) bool {
 	_, ok := set[item]
@@ -21,3 +27,26 @@ func Unique(slice []string) (res []string) {
 
 	return res
 }
+
+// MatchCase returns replacement using the same casing pattern as original, e.x. MASTER -> MAIN and Master -> Main.
+// Replacement is returned as is for lowercase or mixed case originals.
+func MatchCase(original, replacement string) string {
+	if original == "" || replacement == "" {
+		return replacement
+	}
+
+	switch {
+	case strings.ToUpper(original) == original && strings.ToLower(original) != original:
+		return strings.ToUpper(replacement)
+	case isTitle(original):
+		r, size := utf8.DecodeRuneInString(replacement)
+		return string(unicode.ToUpper(r)) + replacement[size:]
+	default:
+		return replacement
+	}
+}
+
+func isTitle(s string) bool {
+	r, size := utf8.DecodeRuneInString(s)
+	return unicode.IsUpper(r) && strings.ToLower(s[size:]) == s[size:]
+}
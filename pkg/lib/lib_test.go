@@ -0,0 +1,30 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type matchCaseTestCase struct {
+	name        string
+	original    string
+	replacement string
+	expected    string
+}
+
+func TestMatchCase(t *testing.T) {
+	cases := []matchCaseTestCase{
+		{name: "Lower", original: "master", replacement: "main", expected: "main"},
+		{name: "Title", original: "Master", replacement: "main", expected: "Main"},
+		{name: "Upper", original: "MASTER", replacement: "main", expected: "MAIN"},
+		{name: "LowerKeepsReplacementCase", original: "whitelist", replacement: "allowList", expected: "allowList"},
+		{name: "Mixed", original: "wHiteList", replacement: "allowlist", expected: "allowlist"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, MatchCase(tc.original, tc.replacement))
+		})
+	}
+}
@@ -0,0 +1,193 @@
+// Package sarif converts findings of flagged terms into SARIF 2.1.0 logs, the format accepted by GitHub code scanning.
+// Every term becomes a rule whose ID only depends on the term itself, so alerts keep their history across runs.
+package sarif
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Version of the SARIF specification logs are written in
+const Version = "2.1.0"
+
+const schemaURI = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// ruleSlugPattern matches runs of characters left out of the readable part of rule IDs
+var ruleSlugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Term is a flagged term, turned into a rule
+type Term struct {
+	Term         string
+	Alternatives []string
+	Category     string
+}
+
+// Finding is a use of a flagged term. Term is the flagged term as configured, and Match the text it matched
+type Finding struct {
+	Path    string
+	Line    int
+	Term    string
+	Match   string
+	Message string
+}
+
+// Log is a SARIF log holding a single run of the tool
+type Log struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []Run  `json:"runs"`
+}
+
+// Run is the output of one run of the tool
+type Run struct {
+	Tool    Tool     `json:"tool"`
+	Results []Result `json:"results"`
+}
+
+// Tool describes the tool producing the log
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+// Driver is the component of the tool holding the rules
+type Driver struct {
+	Name           string `json:"name"`
+	InformationURI string `json:"informationUri,omitempty"`
+	Rules          []Rule `json:"rules"`
+}
+
+// Rule describes one flagged term
+type Rule struct {
+	ID               string         `json:"id"`
+	Name             string         `json:"name"`
+	ShortDescription Message        `json:"shortDescription"`
+	Help             Message        `json:"help"`
+	Properties       RuleProperties `json:"properties"`
+}
+
+// RuleProperties holds the tags GitHub code scanning filters rules by
+type RuleProperties struct {
+	Tags []string `json:"tags,omitempty"`
+}
+
+// Result is one finding
+type Result struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             Message           `json:"message"`
+	Locations           []Location        `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+// Message is a plain text message
+type Message struct {
+	Text string `json:"text"`
+}
+
+// Location points at the line of a file a result is on
+type Location struct {
+	PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+}
+
+// PhysicalLocation is a region within a file
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           Region           `json:"region"`
+}
+
+// ArtifactLocation is the path of a file relative to the root of the repository
+type ArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// Region is a line within a file
+type Region struct {
+	StartLine int `json:"startLine"`
+}
+
+// RuleID returns the stable ID of the rule for a term, e.x. term/master-6f7a3c2e. The readable part is derived from
+// the term, and the hash keeps terms whose readable parts collide apart.
+func RuleID(term string) string {
+	slug := strings.Trim(ruleSlugPattern.ReplaceAllString(strings.ToLower(term), "-"), "-")
+	if slug == "" {
+		slug = "term"
+	}
+
+	sum := sha1.Sum([]byte(term))
+	return fmt.Sprintf("term/%s-%s", slug, hex.EncodeToString(sum[:4]))
+}
+
+// New creates a log of a run of the named tool, with a rule for every term and a result for every finding. Findings
+// of terms that are not in terms are left out.
+func New(name, informationURI string, terms []Term, findings []Finding) *Log {
+	driver := Driver{Name: name, InformationURI: informationURI, Rules: []Rule{}}
+	indexes := make(map[string]int)
+	for i, t := range terms {
+		indexes[t.Term] = i
+
+		help := fmt.Sprintf("`%s` is a flagged term.", t.Term)
+		if len(t.Alternatives) > 0 {
+			help = fmt.Sprintf("%s Consider %s instead.", help, strings.Join(t.Alternatives, ", "))
+		}
+
+		r := Rule{
+			ID:               RuleID(t.Term),
+			Name:             t.Term,
+			ShortDescription: Message{Text: fmt.Sprintf("Use of the flagged term %s", t.Term)},
+			Help:             Message{Text: help},
+		}
+		if t.Category != "" {
+			r.Properties.Tags = []string{t.Category}
+		}
+		driver.Rules = append(driver.Rules, r)
+	}
+
+	results := []Result{}
+	occurrences := make(map[string]int)
+	for _, f := range findings {
+		i, ok := indexes[f.Term]
+		if !ok {
+			continue
+		}
+
+		key := strings.Join([]string{f.Path, f.Term, strings.ToLower(f.Match)}, "\x00")
+		occurrences[key]++
+
+		results = append(results, Result{
+			RuleID:    driver.Rules[i].ID,
+			RuleIndex: i,
+			Level:     "warning",
+			Message:   Message{Text: f.Message},
+			Locations: []Location{{PhysicalLocation: PhysicalLocation{
+				ArtifactLocation: ArtifactLocation{URI: f.Path},
+				Region:           Region{StartLine: f.Line},
+			}}},
+			// Line numbers shift as files change, the file, matched text and how many times it was matched before don't
+			PartialFingerprints: map[string]string{"termCheck/v1": fingerprint(key, occurrences[key])},
+		})
+	}
+
+	return &Log{
+		Schema:  schemaURI,
+		Version: Version,
+		Runs:    []Run{{Tool: Tool{Driver: driver}, Results: results}},
+	}
+}
+
+// Write writes the log as indented JSON
+func (l *Log) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(l)
+}
+
+func fingerprint(key string, occurrence int) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s\x00%d", key, occurrence)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,83 @@
+package sarif
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ruleIDTestCase struct {
+	name     string
+	term     string
+	expected string
+}
+
+func TestRuleID(t *testing.T) {
+	cases := []ruleIDTestCase{
+		{
+			name:     "PlainTerm",
+			term:     "master",
+			expected: "term/master-4f26aeaf",
+		},
+		{
+			name:     "PatternIsSlugged",
+			term:     `(?i)\bwhite[- ]?list\b`,
+			expected: "term/i-bwhite-list-b-4d6bd209",
+		},
+		{
+			name:     "NoReadablePart",
+			term:     `.+`,
+			expected: "term/term-7b0ec43e",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, RuleID(tc.term))
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	terms := []Term{
+		{Term: "master", Alternatives: []string{"main", "primary"}, Category: "hierarchy"},
+		{Term: "whitelist"},
+	}
+	findings := []Finding{
+		{Path: "README.md", Line: 3, Term: "master", Match: "Master", Message: "Avoid master"},
+		{Path: "README.md", Line: 9, Term: "master", Match: "master", Message: "Avoid master"},
+		{Path: "main.go", Line: 1, Term: "whitelist", Match: "whitelist", Message: "Avoid whitelist"},
+		{Path: "main.go", Line: 2, Term: "unknown", Match: "unknown", Message: "Not a configured term"},
+	}
+
+	l := New("term-check", "https://github.com/zendesk/term-check", terms, findings)
+
+	assert.Equal(t, "2.1.0", l.Version)
+	if !assert.Len(t, l.Runs, 1) {
+		return
+	}
+	run := l.Runs[0]
+
+	assert.Equal(t, "term-check", run.Tool.Driver.Name)
+	assert.Equal(t, []string{RuleID("master"), RuleID("whitelist")}, []string{run.Tool.Driver.Rules[0].ID, run.Tool.Driver.Rules[1].ID})
+	assert.Equal(t, "`master` is a flagged term. Consider main, primary instead.", run.Tool.Driver.Rules[0].Help.Text)
+	assert.Equal(t, []string{"hierarchy"}, run.Tool.Driver.Rules[0].Properties.Tags)
+
+	if assert.Len(t, run.Results, 3) {
+		assert.Equal(t, 0, run.Results[0].RuleIndex)
+		assert.Equal(t, 1, run.Results[2].RuleIndex)
+		assert.Equal(t, "main.go", run.Results[2].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+		assert.Equal(t, 1, run.Results[2].Locations[0].PhysicalLocation.Region.StartLine)
+		// Repeated matches in a file need distinct fingerprints
+		assert.NotEqual(t, run.Results[0].PartialFingerprints, run.Results[1].PartialFingerprints)
+	}
+
+	var buf bytes.Buffer
+	if assert.NoError(t, l.Write(&buf)) {
+		var decoded map[string]interface{}
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+		assert.Equal(t, schemaURI, decoded["$schema"])
+	}
+}